@@ -0,0 +1,39 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import "io/fs"
+
+// readFileFS adds ReadFile to a filesystem which only implements Open
+type readFileFS struct {
+	fs.FS
+}
+
+func (rfs readFileFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(rfs.FS, name)
+}
+
+// AdaptFS returns fsys as an fs.ReadFileFS. Filesystems which only implement Open,
+// such as *zip.Reader or os.DirFS in older Go releases, are wrapped so ReadFile is
+// implemented with fs.ReadFile. Returns nil for a nil fsys.
+func AdaptFS(fsys fs.FS) fs.ReadFileFS {
+	if fsys == nil {
+		return nil
+	}
+	if rfs, ok := fsys.(fs.ReadFileFS); ok {
+		return rfs
+	}
+	return readFileFS{fsys}
+}
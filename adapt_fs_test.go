@@ -0,0 +1,175 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildZip creates an in-memory zip archive of files
+func buildZip(t *testing.T, files map[string]string) *zip.Reader {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return reader
+}
+
+func TestAdaptFS(t *testing.T) {
+	t.Run("ReadFileFS is returned unchanged", func(t *testing.T) {
+		adapted := AdaptFS(testFiles)
+		_, wrapped := adapted.(readFileFS)
+		assert.False(t, wrapped)
+	})
+
+	t.Run("Open-only filesystem is wrapped", func(t *testing.T) {
+		archive := buildZip(t, map[string]string{"a.txt": "from zip"})
+		_, isReadFileFS := fs.FS(archive).(fs.ReadFileFS)
+		require.False(t, isReadFileFS)
+
+		adapted := AdaptFS(archive)
+		data, err := adapted.ReadFile("a.txt")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("from zip"), data)
+
+		_, err = adapted.ReadFile("missing.txt")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("Nil filesystem", func(t *testing.T) {
+		assert.Nil(t, AdaptFS(nil))
+	})
+}
+
+func TestServeFromZip(t *testing.T) {
+	archive := buildZip(t, map[string]string{
+		"dist/index.html":   "<h1>zipped</h1>",
+		"dist/css/site.css": "body{}",
+	})
+	server, err := NewAssetServer("/assets/", AdaptFS(archive))
+	require.Nil(t, err)
+	server.FSPrefix = "dist/"
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{"/assets/index.html", http.StatusOK, utf8Type(mimeTypeHTML), "<h1>zipped</h1>"},
+		{"/assets/css/site.css", http.StatusOK, utf8Type(mimeTypeCSS), "body{}"},
+		{"/assets/missing.css", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// openOnlyFS hides every method of a filesystem but Open
+type openOnlyFS struct {
+	files fs.FS
+}
+
+func (o openOnlyFS) Open(name string) (fs.File, error) {
+	return o.files.Open(name)
+}
+
+func TestNewAssetServerAdaptsFS(t *testing.T) {
+	get := func(server *AssetServer, p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+		return w
+	}
+
+	t.Run("Open-only filesystem", func(t *testing.T) {
+		files := openOnlyFS{fstest.MapFS{
+			"index.html":     &fstest.MapFile{Data: []byte("<h1>home</h1>")},
+			"js/app.js":      &fstest.MapFile{Data: []byte("console.log(1)")},
+			"js/app.js.br":   &fstest.MapFile{Data: []byte("compressed")},
+			"nested/a/b.css": &fstest.MapFile{Data: []byte("b{}")},
+		}}
+		server, err := NewAssetServer("/assets/", files)
+		require.NoError(t, err)
+		server.BrotliSuffix = ".br"
+
+		w := get(server, "/assets/index.html")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeHTML), w.Header().Get("Content-Type"))
+		assert.Equal(t, "<h1>home</h1>", w.Body.String())
+
+		w = get(server, "/assets/nested/a/b.css")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "b{}", w.Body.String())
+
+		req := httptest.NewRequest("GET", "/assets/js/app.js", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "compressed", w.Body.String())
+
+		assert.Equal(t, http.StatusNotFound, get(server, "/assets/missing.js").Code)
+	})
+
+	t.Run("Zip archive without AdaptFS", func(t *testing.T) {
+		archive := buildZip(t, map[string]string{"dist/index.html": "<h1>zipped</h1>"})
+		server, err := NewAssetServerWithOptions("/assets/", archive, WithFSPrefix("dist/"))
+		require.NoError(t, err)
+
+		w := get(server, "/assets/index.html")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<h1>zipped</h1>", w.Body.String())
+	})
+
+	t.Run("ReadFileFS is used directly", func(t *testing.T) {
+		counting := &countingFS{files: fstest.MapFS{"a.txt": &fstest.MapFile{Data: []byte("a")}}}
+		server, err := NewAssetServer("/assets/", counting)
+		require.NoError(t, err)
+
+		_, wrapped := server.files.(readFileFS)
+		assert.False(t, wrapped)
+		assert.Equal(t, http.StatusOK, get(server, "/assets/a.txt").Code)
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+}
@@ -15,13 +15,17 @@
 package statica
 
 import (
+	"compress/gzip"
 	"embed"
+	"fmt"
 	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 )
 
 type wrappedDirFS struct {
@@ -360,6 +364,76 @@ func setupBenchmarkAssets(b *testing.B) string {
 	return tempDir
 }
 
+// mimeInferencePaths is a realistic mix of requested paths, weighted towards the
+// scripts, styles, and images that dominate page loads
+var mimeInferencePaths = []string{
+	"js/app.js", "js/vendor.js", "js/chunk-2f1a.js", "css/site.css", "css/print.css",
+	"index.html", "docs/getting-started.html", "img/logo.png", "img/hero.jpg",
+	"img/photo.jpeg", "fonts/inter.woff2", "fonts/inter.woff", "data/config.json",
+	"robots.txt", "downloads/archive.zip",
+}
+
+// extensionMimeTypes builds the extension map used by the map-based inference strategy
+func extensionMimeTypes(server *AssetServer) map[string]string {
+	types := make(map[string]string, len(server.typers))
+	for _, typer := range server.typers {
+		if ext, ok := simpleExtension(typer.expr); ok {
+			if _, claimed := types[ext]; !claimed {
+				types[ext] = typer.mimeType
+			}
+		}
+	}
+	return types
+}
+
+// mapMimeType is the proposed map-based alternative to matchMimeType
+func mapMimeType(types map[string]string, filePath string) string {
+	ext := path.Ext(filePath)
+	if ext == "" {
+		return mimeTypeUnknown
+	}
+	if mimeType, ok := types[ext[1:]]; ok {
+		return mimeType
+	}
+	return mimeTypeUnknown
+}
+
+func TestMapMimeTypeMatchesRegex(t *testing.T) {
+	server, err := NewAssetServer("/assets/", benchmarkAssets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := extensionMimeTypes(server)
+	for _, p := range mimeInferencePaths {
+		if got, want := mapMimeType(types, p), server.matchMimeType(p); got != want {
+			t.Errorf("%s: map strategy returned %s, regex strategy returned %s", p, got, want)
+		}
+	}
+}
+
+func BenchmarkInferMimeType(b *testing.B) {
+	server, err := NewAssetServer("/assets/", benchmarkAssets)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("Regex", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			server.matchMimeType(mimeInferencePaths[i%len(mimeInferencePaths)])
+		}
+	})
+
+	b.Run("Map", func(b *testing.B) {
+		types := extensionMimeTypes(server)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			mapMimeType(types, mimeInferencePaths[i%len(mimeInferencePaths)])
+		}
+	})
+}
+
 func generateLargeContent() string {
 	content := "This is a large file for benchmarking purposes.\n"
 	result := ""
@@ -390,3 +464,80 @@ func init() {
 		}
 	}
 }
+
+func BenchmarkConditionalGET(b *testing.B) {
+	for _, cacheETags := range []bool{false, true} {
+		name := "ReadsAsset"
+		if cacheETags {
+			name = "CacheETags"
+		}
+		b.Run(name, func(b *testing.B) {
+			cachingFS, err := NewDefaultCachingFS(benchmarkAssets)
+			if err != nil {
+				b.Fatal(err)
+			}
+			server, err := NewAssetServer("/assets/", cachingFS)
+			if err != nil {
+				b.Fatal(err)
+			}
+			server.FSPrefix = "benchmark_assets/"
+			server.CacheETags = cacheETags
+
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/style.css", nil))
+			req := httptest.NewRequest("GET", "/assets/style.css", nil)
+			req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				server.ServeHTTP(w, req)
+				if w.Code != http.StatusNotModified {
+					b.Fatalf("Expected status 304, got %d", w.Code)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGzipLevel(b *testing.B) {
+	var content []byte
+	for i := 0; len(content) < 64*1024; i++ {
+		content = fmt.Appendf(content, ".rule-%d { margin: %dpx; color: #%06x; }\n", i, i%97, i*7919%0xffffff)
+	}
+	levels := []struct {
+		name  string
+		level int
+	}{
+		{"HuffmanOnly", gzip.HuffmanOnly},
+		{"BestSpeed", gzip.BestSpeed},
+		{"Default", gzip.DefaultCompression},
+		{"BestCompression", gzip.BestCompression},
+	}
+	for _, tt := range levels {
+		b.Run(tt.name, func(b *testing.B) {
+			server, err := NewAssetServer("/assets/", fstest.MapFS{
+				"style.css": &fstest.MapFile{Data: content},
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			server.EnableGzip = true
+			server.GzipLevel = tt.level
+			req := httptest.NewRequest("GET", "/assets/style.css", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			var size int
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				server.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					b.Fatalf("Expected status 200, got %d", w.Code)
+				}
+				size = w.Body.Len()
+			}
+			b.ReportMetric(float64(size), "bytes/response")
+		})
+	}
+}
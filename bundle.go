@@ -0,0 +1,86 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var ErrBadBundle = errors.New("bundle request is invalid")
+
+// MaxBundleFiles is the most files one bundle request may name, so a single request
+// can't fan out into unbounded reads
+const MaxBundleFiles = 32
+
+// BundleHandler returns a handler which concatenates several assets into one response,
+// reducing round trips for HTTP/1.1 clients. Assets are named relative to the route in
+// the comma separated "files" query parameter, e.g. /assets/bundle?files=a.css,b.css,
+// and must all share a mime type. Files are joined with a newline and the response is
+// otherwise treated like a single asset named after the first file. Precompressed
+// variants are not used since they can't be concatenated, and names of variants, such
+// as app.css.br, are refused. Names also pass the path checks of a direct request, and
+// at most MaxBundleFiles may be given, or the whole bundle is refused.
+func (server *AssetServer) BundleHandler() http.Handler {
+	return server.withMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if !server.methodAllowed(w, r) {
+			return
+		}
+		var names []string
+		for _, name := range strings.Split(r.URL.Query().Get("files"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			server.fail(w, r, fmt.Errorf("%w: no files requested", ErrBadBundle))
+			return
+		}
+		if len(names) > MaxBundleFiles {
+			server.fail(w, r, fmt.Errorf("%w: %d files requested, at most %d allowed", ErrBadBundle, len(names), MaxBundleFiles))
+			return
+		}
+		for _, name := range names {
+			if !server.guardPath(w, r, name) {
+				return
+			}
+			if _, disabled := server.disabledVariant(name); disabled || server.directEncoding(name) != "" {
+				server.fail(w, r, fmt.Errorf("%w: %s is a compressed variant", ErrBadBundle, name))
+				return
+			}
+		}
+		mimeType := server.inferMimeType(names[0])
+		var bundle bytes.Buffer
+		for i, name := range names {
+			if nameType := server.inferMimeType(name); nameType != mimeType {
+				server.fail(w, r, fmt.Errorf("%w: %s is %s, expected %s", ErrBadBundle, name, nameType, mimeType))
+				return
+			}
+			data, _, err := server.readFile(r.Context(), name, nil)
+			if err != nil {
+				server.fail(w, r, err)
+				return
+			}
+			if i > 0 {
+				bundle.WriteByte('\n')
+			}
+			bundle.Write(data)
+		}
+		server.writeAsset(w, r, names[0], bundle.Bytes(), "", server.assetModTime(names...))
+	})
+}
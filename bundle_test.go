@@ -0,0 +1,225 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleHandler(t *testing.T) {
+	files := fstest.MapFS{
+		"a.css":       &fstest.MapFile{Data: []byte(".a{}")},
+		"b.css":       &fstest.MapFile{Data: []byte(".b{}")},
+		"b.css.br":    &fstest.MapFile{Data: []byte("compressed")},
+		"icons/c.css": &fstest.MapFile{Data: []byte(".c{}")},
+		"app.js":      &fstest.MapFile{Data: []byte("app()")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.BrotliSuffix = ".br"
+	handler := server.BundleHandler()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Concatenates files in order", "files=b.css,a.css,icons/c.css", http.StatusOK, ".b{}\n.a{}\n.c{}"},
+		{"Single file", "files=a.css", http.StatusOK, ".a{}"},
+		{"Whitespace and empty names ignored", "files=a.css,%20b.css,", http.StatusOK, ".a{}\n.b{}"},
+		{"Mixed mime types rejected", "files=a.css,app.js", http.StatusBadRequest, ""},
+		{"No files rejected", "files=", http.StatusBadRequest, ""},
+		{"Missing parameter rejected", "", http.StatusBadRequest, ""},
+		{"Missing file", "files=a.css,missing.css", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/assets/bundle?"+tt.query, nil)
+			req.Header.Set("Accept-Encoding", "br")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
+				assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBundleHandlerPathGuards(t *testing.T) {
+	files := fstest.MapFS{
+		"a.css":            &fstest.MapFile{Data: []byte(".a{}")},
+		".private.css":     &fstest.MapFile{Data: []byte(".secret{}")},
+		"maintenance.html": &fstest.MapFile{Data: []byte("<h1>Back soon</h1>")},
+	}
+	request := func(server *AssetServer, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/assets/bundle?"+query, nil)
+		w := httptest.NewRecorder()
+		server.BundleHandler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Dotfile refuses the bundle", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.HideDotfiles = true
+
+		w := request(server, "files=a.css,.private.css")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NotContains(t, w.Body.String(), ".secret{}")
+	})
+
+	t.Run("Parent directory name refuses the bundle", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+
+		w := request(server, "files=a.css,../a.css")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Maintenance mode serves the maintenance page", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.Maintenance = &MaintenanceConfig{File: "maintenance.html"}
+
+		w := request(server, "files=a.css")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "<h1>Back soon</h1>", w.Body.String())
+	})
+
+	t.Run("Exempt names are bundled", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.Maintenance = &MaintenanceConfig{File: "maintenance.html", Exempt: regexp.MustCompile(`^a\.css$`)}
+
+		w := request(server, "files=a.css")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, ".a{}", w.Body.String())
+	})
+}
+
+func TestBundleHandlerVariants(t *testing.T) {
+	files := fstest.MapFS{
+		"a.css":    &fstest.MapFile{Data: []byte(".a{}")},
+		"b.css.br": &fstest.MapFile{Data: []byte("brotli bytes")},
+		"c.css":    &fstest.MapFile{Data: []byte(".c{}")},
+		"c.css.gz": &fstest.MapFile{Data: []byte("gzip bytes")},
+	}
+	request := func(server *AssetServer, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/assets/bundle?"+query, nil)
+		w := httptest.NewRecorder()
+		server.BundleHandler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Precompressed variant refuses the bundle", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+
+		w := request(server, "files=a.css,b.css.br")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NotContains(t, w.Body.String(), "brotli bytes")
+	})
+
+	t.Run("Disabled variant refuses the bundle", func(t *testing.T) {
+		for _, policy := range []DisabledVariantPolicy{DisabledVariantLiteral, DisabledVariantNotFound, DisabledVariantOriginal} {
+			server, err := NewAssetServer("/assets/", files)
+			require.Nil(t, err)
+			server.DisabledVariants = policy
+
+			w := request(server, "files=c.css.gz")
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.NotContains(t, w.Body.String(), "gzip bytes")
+		}
+	})
+
+	t.Run("Too many names refuse the bundle", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+
+		w := request(server, "files="+strings.Repeat("a.css,", MaxBundleFiles)+"c.css")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Names up to the limit are bundled", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+
+		w := request(server, "files="+strings.Repeat("a.css,", MaxBundleFiles-1)+"c.css")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, strings.Repeat(".a{}\n", MaxBundleFiles-1)+".c{}", w.Body.String())
+	})
+}
+
+func TestBundleHandlerLastModified(t *testing.T) {
+	older := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+	files := fstest.MapFS{
+		"a.css": &fstest.MapFile{Data: []byte(".a{}"), ModTime: older},
+		"b.css": &fstest.MapFile{Data: []byte(".b{}"), ModTime: newer},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.ETagFunc = nil
+	get := func(ifModifiedSince time.Time) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/assets/bundle?files=a.css,b.css", nil)
+		if !ifModifiedSince.IsZero() {
+			req.Header.Set("If-Modified-Since", ifModifiedSince.Format(http.TimeFormat))
+		}
+		w := httptest.NewRecorder()
+		server.BundleHandler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Dated by the newest file", func(t *testing.T) {
+		w := get(time.Time{})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, newer.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+	})
+
+	t.Run("A later file changing defeats If-Modified-Since", func(t *testing.T) {
+		w := get(older.Add(time.Hour))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, ".a{}\n.b{}", w.Body.String())
+	})
+
+	t.Run("Unchanged bundle is not modified", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotModified, get(newer).Code)
+	})
+}
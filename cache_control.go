@@ -0,0 +1,103 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DefaultCacheControl is the Cache-Control value DefaultHeaderFunc sends: 7 days in the
+// browser's cache only
+const DefaultCacheControl = "private, max-age=604800"
+
+// ImmutableCacheControl lets any cache keep a response for a year without revalidating,
+// which is only safe for files whose name changes with their contents
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// cacheControlRule sends value as Cache-Control for paths matching expr
+type cacheControlRule struct {
+	expr  *regexp.Regexp
+	value string
+}
+
+// RegisterCacheControl sends value as Cache-Control for assets whose route-relative path
+// matches expr, e.g. `\.html$` with "no-cache" and `\.(js|css|woff2|png)$` with a long
+// max-age. Rules are matched in registration order and the first match wins. A match
+// replaces any Cache-Control set by HeaderFunc; paths matching none keep HeaderFunc's
+// value, or get DefaultCacheControl without one. AssetHeaderFunc runs later and can
+// still override either.
+// Returns false when expr is nil or value is empty.
+// This method is not safe for concurrent use with other configuration
+// methods or with ServeHTTP. Configure the server before serving requests
+func (server *AssetServer) RegisterCacheControl(expr *regexp.Regexp, value string) bool {
+	if expr == nil || value == "" {
+		return false
+	}
+	server.cacheControls = append(server.cacheControls, cacheControlRule{expr: expr, value: value})
+	return true
+}
+
+// setCacheControl applies the registered rules to requestedPath, if there are any.
+// Direct requests for precompressed variants are cached like the original file.
+func (server *AssetServer) setCacheControl(w http.ResponseWriter, requestedPath string) {
+	if len(server.cacheControls) == 0 {
+		return
+	}
+	requestedPath = server.variantBase(requestedPath)
+	for _, rule := range server.cacheControls {
+		if rule.expr.MatchString(requestedPath) {
+			w.Header().Set("Cache-Control", rule.value)
+			return
+		}
+	}
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", DefaultCacheControl)
+	}
+}
+
+// ImmutableHeaderFunc returns an AssetHeaderFunc sending ImmutableCacheControl for
+// assets whose route-relative path matches expr, such as content-hashed names like
+// app.3f2a9c1b.js, and DefaultCacheControl for the rest unless HeaderFunc already set
+// Cache-Control.
+func ImmutableHeaderFunc(expr *regexp.Regexp) StaticaAssetHeaderFunc {
+	return func(w http.ResponseWriter, r *http.Request, asset AssetInfo) {
+		if expr.MatchString(asset.Path) {
+			w.Header().Set("Cache-Control", ImmutableCacheControl)
+		} else if w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", DefaultCacheControl)
+		}
+	}
+}
+
+// AgeBasedCacheControl returns an AssetHeaderFunc setting "Cache-Control: max-age" to
+// fn(age) seconds, where age is how long ago the served file was modified, so that files
+// which haven't changed in a while can be cached longer. Files without a modification
+// time, and negative results from fn, get no Cache-Control header from it.
+func AgeBasedCacheControl(fn func(age time.Duration) int) StaticaAssetHeaderFunc {
+	return func(w http.ResponseWriter, r *http.Request, asset AssetInfo) {
+		modTime := asset.ModTime()
+		if modTime.IsZero() {
+			return
+		}
+		maxAge := fn(max(time.Since(modTime), 0))
+		if maxAge < 0 {
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(maxAge))
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgeBasedCacheControl(t *testing.T) {
+	now := time.Now()
+	files := fstest.MapFS{
+		"static/old.css":     &fstest.MapFile{Data: []byte("old"), ModTime: now.Add(-30 * 24 * time.Hour)},
+		"static/fresh.css":   &fstest.MapFile{Data: []byte("fresh"), ModTime: now.Add(-time.Minute)},
+		"static/undated.css": &fstest.MapFile{Data: []byte("undated")},
+		"static/future.css":  &fstest.MapFile{Data: []byte("future"), ModTime: now.Add(time.Hour)},
+	}
+	// a tenth of the file's age, as in heuristic freshness
+	tenth := func(age time.Duration) int {
+		return int(age.Seconds() / 10)
+	}
+	newServer := func(t *testing.T, fn func(time.Duration) int) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.FSPrefix = "static/"
+		server.AssetHeaderFunc = AgeBasedCacheControl(fn)
+		return server
+	}
+	get := func(server *AssetServer, p string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", p, nil))
+		return recorder
+	}
+
+	t.Run("Older files are cached longer", func(t *testing.T) {
+		server := newServer(t, tenth)
+		old := get(server, "/assets/old.css")
+		fresh := get(server, "/assets/fresh.css")
+
+		assert.Equal(t, http.StatusOK, old.Code)
+		assert.Equal(t, "max-age=259200", old.Header().Get("Cache-Control"))
+		assert.Regexp(t, `^max-age=[56]$`, fresh.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Files without a modification time", func(t *testing.T) {
+		server := newServer(t, tenth)
+		recorder := get(server, "/assets/undated.css")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Future modification times count as new", func(t *testing.T) {
+		server := newServer(t, func(age time.Duration) int {
+			assert.Zero(t, age)
+			return 0
+		})
+		recorder := get(server, "/assets/future.css")
+
+		assert.Equal(t, "max-age=0", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Negative max-age sets no header", func(t *testing.T) {
+		server := newServer(t, func(age time.Duration) int { return -1 })
+		recorder := get(server, "/assets/old.css")
+
+		assert.Empty(t, recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Replaces the HeaderFunc value", func(t *testing.T) {
+		server := newServer(t, tenth)
+		server.HeaderFunc = DefaultHeaderFunc
+		recorder := get(server, "/assets/old.css")
+
+		assert.Equal(t, []string{"max-age=259200"}, recorder.Header().Values("Cache-Control"))
+	})
+
+	t.Run("Served bytes have no modification time", func(t *testing.T) {
+		server := newServer(t, tenth)
+		recorder := httptest.NewRecorder()
+		server.ServeBytes(recorder, httptest.NewRequest("GET", "/assets/config.css", nil), "config.css", []byte("generated"))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Cache-Control"))
+	})
+}
+
+func TestImmutableHeaderFunc(t *testing.T) {
+	files := fstest.MapFS{
+		"app.3f2a9c1b.js":          &fstest.MapFile{Data: []byte("fingerprinted")},
+		"app.js":                   &fstest.MapFile{Data: []byte("plain")},
+		"css/site.0badc0de.css":    &fstest.MapFile{Data: []byte("fingerprinted")},
+		"css/site.0badc0de.css.br": &fstest.MapFile{Data: []byte("compressed")},
+	}
+	fingerprinted := regexp.MustCompile(`\.[0-9a-f]{8}\.[a-z0-9]+$`)
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		server.AssetHeaderFunc = ImmutableHeaderFunc(fingerprinted)
+		return server
+	}
+	get := func(server *AssetServer, p string, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", p, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	t.Run("Fingerprinted file is immutable", func(t *testing.T) {
+		recorder := get(newServer(t), "/assets/app.3f2a9c1b.js", "")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, ImmutableCacheControl, recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Precompressed variant is immutable", func(t *testing.T) {
+		recorder := get(newServer(t), "/assets/css/site.0badc0de.css", "br")
+
+		assert.Equal(t, "br", recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, ImmutableCacheControl, recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Plain file gets the default", func(t *testing.T) {
+		recorder := get(newServer(t), "/assets/app.js", "")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, DefaultCacheControl, recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Replaces the HeaderFunc value for matches only", func(t *testing.T) {
+		server := newServer(t)
+		server.HeaderFunc = func(w http.ResponseWriter, data []byte) {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+
+		assert.Equal(t, []string{ImmutableCacheControl}, get(server, "/assets/app.3f2a9c1b.js", "").Header().Values("Cache-Control"))
+		assert.Equal(t, []string{"no-cache"}, get(server, "/assets/app.js", "").Header().Values("Cache-Control"))
+	})
+}
+
+func TestRegisterCacheControl(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html":            &fstest.MapFile{Data: []byte("<html></html>")},
+		"fonts/inter.woff2":     &fstest.MapFile{Data: []byte("font")},
+		"js/app.js":             &fstest.MapFile{Data: []byte("console.log(1)")},
+		"js/app.js.br":          &fstest.MapFile{Data: []byte("br")},
+		"data/feed.json":        &fstest.MapFile{Data: []byte("{}")},
+		"img/photos/beach.webp": &fstest.MapFile{Data: []byte("webp")},
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		require.True(t, server.RegisterCacheControl(regexp.MustCompile(`\.html$`), "no-cache, max-age=60"))
+		require.True(t, server.RegisterCacheControl(regexp.MustCompile(`\.(js|css|woff2|webp)$`), "public, max-age=31536000"))
+		return server
+	}
+	get := func(server *AssetServer, p string, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", p, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	t.Run("HTML is cached briefly", func(t *testing.T) {
+		recorder := get(newServer(t), "/assets/index.html", "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, []string{"no-cache, max-age=60"}, recorder.Header().Values("Cache-Control"))
+	})
+
+	t.Run("Fonts are cached long", func(t *testing.T) {
+		recorder := get(newServer(t), "/assets/fonts/inter.woff2", "")
+		assert.Equal(t, []string{"public, max-age=31536000"}, recorder.Header().Values("Cache-Control"))
+		recorder = get(newServer(t), "/assets/img/photos/beach.webp", "")
+		assert.Equal(t, "public, max-age=31536000", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Unmatched paths fall back to the default", func(t *testing.T) {
+		server := newServer(t)
+		recorder := get(server, "/assets/data/feed.json", "")
+		assert.Equal(t, []string{DefaultCacheControl}, recorder.Header().Values("Cache-Control"))
+
+		server.HeaderFunc = DefaultHeaderFunc
+		recorder = get(server, "/assets/data/feed.json", "")
+		assert.Equal(t, []string{DefaultCacheControl}, recorder.Header().Values("Cache-Control"))
+
+		server.HeaderFunc = func(w http.ResponseWriter, data []byte) {
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("X-Custom", "kept")
+		}
+		recorder = get(server, "/assets/data/feed.json", "")
+		assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+		recorder = get(server, "/assets/index.html", "")
+		assert.Equal(t, "no-cache, max-age=60", recorder.Header().Get("Cache-Control"))
+		assert.Equal(t, "kept", recorder.Header().Get("X-Custom"))
+	})
+
+	t.Run("First match wins", func(t *testing.T) {
+		server := newServer(t)
+		require.True(t, server.RegisterCacheControl(regexp.MustCompile(`^js/`), "no-store"))
+		recorder := get(server, "/assets/js/app.js", "")
+		assert.Equal(t, "public, max-age=31536000", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Variants are cached like the original", func(t *testing.T) {
+		server := newServer(t)
+		recorder := get(server, "/assets/js/app.js", "br")
+		assert.Equal(t, "br", recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, "public, max-age=31536000", recorder.Header().Get("Cache-Control"))
+
+		recorder = get(server, "/assets/js/app.js.br", "br")
+		assert.Equal(t, "public, max-age=31536000", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("AssetHeaderFunc overrides rules", func(t *testing.T) {
+		server := newServer(t)
+		server.AssetHeaderFunc = ImmutableHeaderFunc(regexp.MustCompile(`\.js$`))
+		recorder := get(server, "/assets/js/app.js", "")
+		assert.Equal(t, ImmutableCacheControl, recorder.Header().Get("Cache-Control"))
+		recorder = get(server, "/assets/index.html", "")
+		assert.Equal(t, "no-cache, max-age=60", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Streamed assets", func(t *testing.T) {
+		server := newServer(t)
+		server.StreamThreshold = 1
+		recorder := get(server, "/assets/index.html", "")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "no-cache, max-age=60", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("No rules leave Cache-Control alone", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		recorder := get(server, "/assets/index.html", "")
+		assert.Empty(t, recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Invalid rules", func(t *testing.T) {
+		server := newServer(t)
+		assert.False(t, server.RegisterCacheControl(nil, "no-cache"))
+		assert.False(t, server.RegisterCacheControl(regexp.MustCompile(`.`), ""))
+	})
+}
@@ -3,9 +3,17 @@ package statica
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/maypok86/otter/v2"
+	"github.com/maypok86/otter/v2/stats"
 )
 
 const DefaultMaxEntries = 1000
@@ -13,49 +21,176 @@ const DefaultInitialCapacity = 100
 
 // FSLoader implements otter.Loader
 type FSLoader struct {
-	files fs.ReadFileFS
+	files  fs.ReadFileFS
+	onMiss func(filePath string, loadDuration time.Duration)
+	// maxFileSize, when positive, is the largest file loaded into the cache
+	maxFileSize int
 }
 
-func (loader *FSLoader) load(filePath string) ([]byte, error) {
-	data, err := loader.files.ReadFile(filePath)
+// oversizedError carries a file too large to cache out of the loader, since otter only
+// skips inserting entries whose load fails
+type oversizedError struct {
+	data []byte
+}
+
+func (err *oversizedError) Error() string {
+	return "file exceeds MaxFileSize"
+}
+
+// namespaceSeparator joins a namespace to a file path in cache keys. Valid file paths
+// never contain it.
+const namespaceSeparator = "\x00"
+
+// cacheKey scopes filePath to namespace ns. The empty namespace is the bare path, so
+// ReadFile and ReadFileNS with an empty namespace share entries.
+func cacheKey(ns, filePath string) string {
+	if ns == "" {
+		return filePath
+	}
+	return ns + namespaceSeparator + filePath
+}
+
+// keyPath returns the file path of a cache key created by cacheKey
+func keyPath(key string) string {
+	if _, filePath, found := strings.Cut(key, namespaceSeparator); found {
+		return filePath
+	}
+	return key
+}
+
+// canceled reports whether err ended a read because a context was canceled or expired
+func canceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// read reads filePath from the underlying filesystem, through ReadFileCtx when it can be
+// canceled. Other filesystems can't be interrupted mid-read, so ctx is only checked first.
+func (loader *FSLoader) read(ctx context.Context, filePath string) ([]byte, error) {
+	if ctxFiles, ok := loader.files.(ContextFS); ok {
+		return ctxFiles.ReadFileCtx(ctx, filePath)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return loader.files.ReadFile(filePath)
+}
+
+func (loader *FSLoader) load(ctx context.Context, key string) ([]byte, error) {
+	data, err := loader.read(ctx, keyPath(key))
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
 			return nil, otter.ErrNotFound
 		}
 		return nil, err
 	}
+	if loader.maxFileSize > 0 && len(data) > loader.maxFileSize {
+		return nil, &oversizedError{data: data}
+	}
 	return data, nil
 }
 
-func (loader *FSLoader) Load(ctx context.Context, filePath string) ([]byte, error) {
-	return loader.load(filePath)
+func (loader *FSLoader) Load(ctx context.Context, key string) ([]byte, error) {
+	if loader.onMiss == nil {
+		return loader.load(ctx, key)
+	}
+	start := time.Now()
+	data, err := loader.load(ctx, key)
+	loader.onMiss(keyPath(key), time.Since(start))
+	return data, err
 }
 
-func (loader *FSLoader) Reload(ctx context.Context, filePath string, data []byte) ([]byte, error) {
-	return loader.load(filePath)
+func (loader *FSLoader) Reload(ctx context.Context, key string, data []byte) ([]byte, error) {
+	return loader.load(ctx, key)
 }
 
 var _ otter.Loader[string, []byte] = (*FSLoader)(nil)
 
 type CachingFSOption struct {
-	MaxEntryCount   int
+	MaxEntryCount int
+	// MaxBytes, when positive, caps the total size of cached file contents instead of the
+	// number of entries, so memory use is predictable whatever the file sizes. MaxEntryCount
+	// is ignored for file contents when MaxBytes is set, but still bounds remembered misses.
+	MaxBytes        uint64
 	InitialCapacity int
+	// OnMiss is called after each cache miss with the time spent reading from the
+	// underlying filesystem, whether or not the read succeeded
+	OnMiss func(filePath string, loadDuration time.Duration)
+	// TTLFunc, when set, returns how long the entry for filePath stays cached after it
+	// is loaded. Zero or negative durations keep the entry until it is evicted.
+	TTLFunc func(filePath string) time.Duration
+	// TTL, when positive, is how long every entry stays cached after it is loaded, for
+	// filesystems which change periodically. Zero keeps entries until they are evicted.
+	// TTLFunc takes precedence when both are set.
+	TTL time.Duration
+	// CacheMisses remembers files which don't exist, so that repeated reads of them,
+	// such as AssetServer probing for absent Brotli variants, don't reach the
+	// underlying filesystem. Misses are bounded by MaxEntryCount and expire after TTL or
+	// TTLFunc like other entries.
+	CacheMisses bool
+	// CacheMissFunc, when set with CacheMisses, reports whether the miss for filePath
+	// should be remembered. Returning false for variant suffixes such as ".br" keeps
+	// systematic variant probes from filling the cache with negative entries.
+	CacheMissFunc func(filePath string) bool
+	// MaxFileSize, when positive, is the largest file in bytes kept in the cache. Larger
+	// files are read from the underlying filesystem on every ReadFile, so one big file
+	// can't evict many small hot ones.
+	MaxFileSize int
+	// Metrics, when set, is told whether each read was a cache hit or miss
+	Metrics MetricsCollector
+	// CacheOpen makes Open return regular files as seekable in-memory files read through
+	// the cache, like ReadFile, rather than fresh handles from the underlying filesystem.
+	// Only use it for read-only workloads; files are read whole even by callers which only
+	// wanted part of them.
+	CacheOpen bool
 }
 
 // CachingFS uses a pull-through otter.Cache to minimize IO calls
 type CachingFS struct {
-	fs    *FSLoader
-	cache *otter.Cache[string, []byte]
+	fs     *FSLoader
+	cache  *otter.Cache[string, []byte]
+	misses *otter.Cache[string, struct{}]
+	// infos memoizes successful Stat and Lstat results, keyed by cacheKey of the call's
+	// name and the path, so servers sending Last-Modified or refusing symbolic links
+	// don't reach the underlying filesystem on every request
+	infos *otter.Cache[string, fs.FileInfo]
+	// cacheMiss filters which misses are remembered; nil remembers all of them
+	cacheMiss func(filePath string) bool
+	disabled  atomic.Bool
+	metrics   MetricsCollector
+	cacheOpen bool
 }
 
 var _ fs.ReadFileFS = (*CachingFS)(nil)
+var _ NamespacedFS = (*CachingFS)(nil)
+var _ ContextFS = (*CachingFS)(nil)
+var _ ContextNamespacedFS = (*CachingFS)(nil)
+var _ fs.StatFS = (*CachingFS)(nil)
+var _ fs.ReadLinkFS = (*CachingFS)(nil)
+
+// NamespacedFS is implemented by filesystems, such as CachingFS, which can scope the
+// state kept for reads by a namespace
+type NamespacedFS interface {
+	ReadFileNS(ns, filePath string) ([]byte, error)
+}
+
+// ContextFS is implemented by filesystems, such as CachingFS, whose reads stop when ctx
+// is canceled, so a slow read is abandoned along with the request it serves
+type ContextFS interface {
+	ReadFileCtx(ctx context.Context, filePath string) ([]byte, error)
+}
+
+// ContextNamespacedFS is implemented by filesystems which offer ContextFS cancellation
+// for NamespacedFS reads
+type ContextNamespacedFS interface {
+	ReadFileNSCtx(ctx context.Context, ns, filePath string) ([]byte, error)
+}
 
 // NewDefaultCachingFS creates a new CachingFS instance with max cache size
 // and initial capacity set to `DefaultMaxEntries` and `DefaultInitialCapacity`
 // Use NewCachingFS if different values are desired.
 func NewDefaultCachingFS(baseFS fs.ReadFileFS) (*CachingFS, error) {
 	return NewCachingFS(baseFS, &CachingFSOption{
-		MaxEntryCount: DefaultMaxEntries,
+		MaxEntryCount:   DefaultMaxEntries,
 		InitialCapacity: DefaultInitialCapacity,
 	})
 }
@@ -68,40 +203,315 @@ func NewCachingFS(baseFS fs.ReadFileFS, option *CachingFSOption) (*CachingFS, er
 	loader := &FSLoader{
 		files: baseFS,
 	}
+	if option != nil {
+		loader.onMiss = option.OnMiss
+		loader.maxFileSize = option.MaxFileSize
+	}
 	var options otter.Options[string, []byte]
 	options.MaximumSize = DefaultMaxEntries
 	options.InitialCapacity = DefaultInitialCapacity
+	options.StatsRecorder = stats.NewCounter()
+	maxEntries := DefaultMaxEntries
 	if option != nil {
 		if option.MaxEntryCount > 0 {
-			options.MaximumSize = option.MaxEntryCount
+			maxEntries = option.MaxEntryCount
+			options.MaximumSize = maxEntries
+		}
+		if option.MaxBytes > 0 {
+			// otter refuses MaximumSize together with MaximumWeight
+			options.MaximumSize = 0
+			options.MaximumWeight = option.MaxBytes
+			options.Weigher = weighEntry
 		}
 		if option.InitialCapacity > 0 {
 			options.InitialCapacity = option.InitialCapacity
 		}
+		options.ExpiryCalculator = entryExpiry[[]byte](option)
 	}
 	cache, err := otter.New(&options)
 	if err != nil {
 		return nil, err
 	}
-	return &CachingFS{
+	infos, err := otter.New(&otter.Options[string, fs.FileInfo]{
+		MaximumSize:      maxEntries,
+		InitialCapacity:  options.InitialCapacity,
+		ExpiryCalculator: entryExpiry[fs.FileInfo](option),
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfs := &CachingFS{
 		fs:    loader,
 		cache: cache,
-	}, nil
+		infos: infos,
+	}
+	if option != nil {
+		cfs.metrics = option.Metrics
+		cfs.cacheOpen = option.CacheOpen
+	}
+	if option != nil && option.CacheMisses {
+		cfs.cacheMiss = option.CacheMissFunc
+		cfs.misses, err = otter.New(&otter.Options[string, struct{}]{
+			MaximumSize:      maxEntries,
+			InitialCapacity:  options.InitialCapacity,
+			ExpiryCalculator: entryExpiry[struct{}](option),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfs, nil
 }
 
-// Open bypasses the cache since the lifetime of the returned fs.File is unknown.
+// entryExpiry returns the expiry for option's TTLFunc or TTL, shared by cached contents
+// and remembered misses, or nil when entries stay until they are evicted
+func entryExpiry[V any](option *CachingFSOption) otter.ExpiryCalculator[string, V] {
+	if option == nil {
+		return nil
+	}
+	if option.TTLFunc != nil {
+		ttlFunc := option.TTLFunc
+		return otter.ExpiryWritingFunc(func(entry otter.Entry[string, V]) time.Duration {
+			return ttlFunc(keyPath(entry.Key))
+		})
+	}
+	if option.TTL > 0 {
+		return otter.ExpiryWriting[string, V](option.TTL)
+	}
+	return nil
+}
+
+// weighEntry weighs cache entries by the size of their contents
+func weighEntry(key string, data []byte) uint32 {
+	return uint32(min(uint64(len(data)), math.MaxUint32))
+}
+
+// Stats returns a snapshot of the cache's hit, miss, load, and eviction counters
+func (cfs *CachingFS) Stats() stats.Stats {
+	return cfs.cache.Stats()
+}
+
+// Open bypasses the cache since the lifetime of the returned fs.File is unknown, unless
+// CacheOpen was set. Directories always come from the underlying filesystem.
 func (cfs *CachingFS) Open(filePath string) (fs.File, error) {
+	if cfs.cacheOpen && !cfs.disabled.Load() {
+		return readThroughFS{files: cfs.fs.files, readFile: cfs.ReadFile}.Open(filePath)
+	}
 	return cfs.fs.files.Open(filePath)
 }
 
+// Stat reports on filePath in the underlying filesystem without reading it, so callers
+// such as fs.Stat don't load files through a cached Open. Successful results are cached
+// like file contents, expiring with TTL or TTLFunc and dropped by Invalidate, so the
+// modification time a server sends stays in step with the cached bytes.
+func (cfs *CachingFS) Stat(filePath string) (fs.FileInfo, error) {
+	return cfs.cachedInfo("stat", filePath, fs.Stat)
+}
+
+// Lstat reports on filePath in the underlying filesystem without following a symbolic
+// link, so servers can refuse links. It is Stat when the filesystem has no links.
+// Results are cached like those of Stat, so a path turning into a link is noticed once
+// its entry goes.
+func (cfs *CachingFS) Lstat(filePath string) (fs.FileInfo, error) {
+	return cfs.cachedInfo("lstat", filePath, fs.Lstat)
+}
+
+// cachedInfo returns the memoized result of the call op for filePath, making it against
+// the underlying filesystem on a miss or while caching is disabled
+func (cfs *CachingFS) cachedInfo(op, filePath string, call func(fs.FS, string) (fs.FileInfo, error)) (fs.FileInfo, error) {
+	if cfs.disabled.Load() {
+		return call(cfs.fs.files, filePath)
+	}
+	key := cacheKey(op, filePath)
+	if info, ok := cfs.infos.GetIfPresent(key); ok {
+		return info, nil
+	}
+	info, err := call(cfs.fs.files, filePath)
+	if err != nil {
+		return nil, err
+	}
+	cfs.infos.Set(key, info)
+	return info, nil
+}
+
+// ReadLink returns the destination of the symbolic link filePath in the underlying
+// filesystem, failing when the filesystem has no links
+func (cfs *CachingFS) ReadLink(filePath string) (string, error) {
+	return fs.ReadLink(cfs.fs.files, filePath)
+}
+
+// SetEnabled toggles caching at runtime. While disabled, ReadFile reads from the
+// underlying filesystem on every call. Re-enabling clears the cache so stale
+// entries from before the cache was disabled are not served. Safe for concurrent use.
+func (cfs *CachingFS) SetEnabled(enabled bool) {
+	if cfs.disabled.Swap(!enabled) && enabled {
+		cfs.InvalidateAll()
+	}
+}
+
+// Enabled reports whether ReadFile is currently served from the cache
+func (cfs *CachingFS) Enabled() bool {
+	return !cfs.disabled.Load()
+}
+
+// Invalidate removes filePath from the cache, in every namespace, so the next ReadFile
+// reloads it from the underlying filesystem. Use it when a file changes underneath a
+// running server, e.g. after a deploy. Safe for concurrent use.
+func (cfs *CachingFS) Invalidate(filePath string) {
+	keys := []string{filePath}
+	collect := func(cached iter.Seq[string]) {
+		for key := range cached {
+			if key != filePath && keyPath(key) == filePath {
+				keys = append(keys, key)
+			}
+		}
+	}
+	collect(cfs.cache.Keys())
+	if cfs.misses != nil {
+		collect(cfs.misses.Keys())
+	}
+	for _, key := range keys {
+		cfs.cache.Invalidate(key)
+		if cfs.misses != nil {
+			cfs.misses.Invalidate(key)
+		}
+	}
+	cfs.infos.Invalidate(cacheKey("stat", filePath))
+	cfs.infos.Invalidate(cacheKey("lstat", filePath))
+}
+
+// InvalidateAll empties the cache. Safe for concurrent use.
+func (cfs *CachingFS) InvalidateAll() {
+	cfs.cache.InvalidateAll()
+	cfs.infos.InvalidateAll()
+	if cfs.misses != nil {
+		cfs.misses.InvalidateAll()
+	}
+}
+
+// Keys returns a sorted snapshot of the paths currently cached, without their contents,
+// for diagnosing which files are resident. Entries read with ReadFileNS are reported as
+// "ns:filePath". Remembered misses are not included.
+func (cfs *CachingFS) Keys() []string {
+	var keys []string
+	for key := range cfs.cache.Keys() {
+		if ns, filePath, found := strings.Cut(key, namespaceSeparator); found {
+			key = ns + ":" + filePath
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 // ReadFile pulls entries into the cache
 func (cfs *CachingFS) ReadFile(filePath string) ([]byte, error) {
-	data, err := cfs.cache.Get(context.Background(), filePath, cfs.fs)
+	return cfs.ReadFileNSCtx(context.Background(), "", filePath)
+}
+
+// ReadFileCtx is ReadFile, but a load from the underlying filesystem stops with ctx's
+// error when ctx is canceled, if the filesystem implements ContextFS, and otherwise
+// isn't started. Cached entries are returned whatever the state of ctx.
+func (cfs *CachingFS) ReadFileCtx(ctx context.Context, filePath string) ([]byte, error) {
+	return cfs.ReadFileNSCtx(ctx, "", filePath)
+}
+
+// ReadFileNS reads filePath through cache entries scoped to namespace ns, so servers
+// sharing one CachingFS can keep their entries apart. The file itself is read from the
+// underlying filesystem at filePath regardless of ns.
+func (cfs *CachingFS) ReadFileNS(ns, filePath string) ([]byte, error) {
+	return cfs.ReadFileNSCtx(context.Background(), ns, filePath)
+}
+
+// ReadFileNSCtx is ReadFileNS with the cancellation of ReadFileCtx
+func (cfs *CachingFS) ReadFileNSCtx(ctx context.Context, ns, filePath string) ([]byte, error) {
+	if strings.Contains(ns, namespaceSeparator) || strings.Contains(filePath, namespaceSeparator) {
+		return nil, &fs.PathError{Op: "read", Path: filePath, Err: fs.ErrInvalid}
+	}
+	if cfs.disabled.Load() {
+		if cfs.metrics != nil {
+			cfs.metrics.CacheMiss(filePath)
+		}
+		return cfs.fs.read(ctx, filePath)
+	}
+	key := cacheKey(ns, filePath)
+	if cfs.misses != nil {
+		if _, missing := cfs.misses.GetIfPresent(key); missing {
+			if cfs.metrics != nil {
+				cfs.metrics.CacheHit(filePath)
+			}
+			return nil, fs.ErrNotExist
+		}
+	}
+	var loader otter.Loader[string, []byte] = cfs.fs
+	loaded := false
+	if cfs.metrics != nil {
+		// Readers waiting on another's load are counted as hits, since they don't read
+		loader = otter.LoaderFunc[string, []byte](func(ctx context.Context, key string) ([]byte, error) {
+			loaded = true
+			return cfs.fs.Load(ctx, key)
+		})
+	}
+	data, err := cfs.cache.Get(ctx, key, loader)
+	if canceled(err) && ctx.Err() == nil {
+		// Waiters share a load's result, so another reader's canceled load fails ours too
+		data, err = cfs.cache.Get(ctx, key, loader)
+	}
+	if cfs.metrics != nil {
+		if loaded {
+			cfs.metrics.CacheMiss(filePath)
+		} else {
+			cfs.metrics.CacheHit(filePath)
+		}
+	}
 	if err != nil {
+		var oversized *oversizedError
+		if errors.As(err, &oversized) {
+			return oversized.data, nil
+		}
 		if errors.Is(err, otter.ErrNotFound) {
+			if cfs.misses != nil && (cfs.cacheMiss == nil || cfs.cacheMiss(filePath)) {
+				cfs.misses.Set(key, struct{}{})
+			}
 			err = fs.ErrNotExist
 		}
 		return nil, err
 	}
 	return data, nil
 }
+
+// Warm reads each of paths into the cache, e.g. the hot assets of a site at startup, so
+// the first requests for them don't reach the underlying filesystem. Every path is
+// attempted; failures are returned together, each naming its path. Warm stops early,
+// returning ctx.Err() with any failures so far, when ctx is done.
+func (cfs *CachingFS) Warm(ctx context.Context, paths []string) error {
+	var errs []error
+	for _, filePath := range paths {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if _, err := cfs.ReadFileCtx(ctx, filePath); err != nil {
+			errs = append(errs, fmt.Errorf("warming %s: %w", filePath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WarmAll walks the underlying filesystem and warms every file in it. Files above
+// MaxFileSize, or beyond MaxEntryCount or MaxBytes, are read but not all kept.
+func (cfs *CachingFS) WarmAll(ctx context.Context) error {
+	var paths []string
+	err := fs.WalkDir(cfs.fs.files, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			paths = append(paths, filePath)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return err
+	}
+	return cfs.Warm(ctx, paths)
+}
@@ -18,9 +18,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/maypok86/otter/v2"
 	"github.com/stretchr/testify/assert"
@@ -91,7 +98,7 @@ func TestFSLoader(t *testing.T) {
 	t.Run("load method behaves same as Load", func(t *testing.T) {
 		loader := &FSLoader{files: cachingTestFiles}
 
-		data1, err1 := loader.load("cached.txt")
+		data1, err1 := loader.load(context.Background(), "cached.txt")
 		data2, err2 := loader.Load(context.Background(), "cached.txt")
 
 		assert.Equal(t, err1, err2)
@@ -205,6 +212,88 @@ func TestCachingFS_Open(t *testing.T) {
 		assert.True(t, errors.Is(err, fs.ErrNotExist))
 		assert.Nil(t, file)
 	})
+
+	newCountingCache := func(t *testing.T, cacheOpen bool) (*CachingFS, *countingFS) {
+		counting := &countingFS{files: fstest.MapFS{
+			"cached.txt":     &fstest.MapFile{Data: []byte("cached content")},
+			"dir/nested.txt": &fstest.MapFile{Data: []byte("nested")},
+		}}
+		cfs, err := NewCachingFS(counting, &CachingFSOption{CacheOpen: cacheOpen})
+		require.NoError(t, err)
+		return cfs, counting
+	}
+
+	t.Run("Cached Open returns cached bytes", func(t *testing.T) {
+		cfs, counting := newCountingCache(t, true)
+		_, err := cfs.ReadFile("cached.txt")
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			file, err := cfs.Open("cached.txt")
+			require.NoError(t, err)
+			data, err := io.ReadAll(file)
+			require.NoError(t, err)
+			assert.Equal(t, "cached content", string(data))
+			require.NoError(t, file.Close())
+		}
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+
+	t.Run("Cached Open supports Seek and Stat", func(t *testing.T) {
+		cfs, _ := newCountingCache(t, true)
+		file, err := cfs.Open("cached.txt")
+		require.NoError(t, err)
+		defer file.Close()
+
+		seeker, ok := file.(io.Seeker)
+		require.True(t, ok)
+		_, err = seeker.Seek(-7, io.SeekEnd)
+		require.NoError(t, err)
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(data))
+		info, err := file.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, "cached.txt", info.Name())
+		assert.Equal(t, int64(14), info.Size())
+	})
+
+	t.Run("Cached Open of a directory", func(t *testing.T) {
+		cfs, _ := newCountingCache(t, true)
+		entries, err := fs.ReadDir(cfs, "dir")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "nested.txt", entries[0].Name())
+	})
+
+	t.Run("Cached Open of a missing file", func(t *testing.T) {
+		cfs, _ := newCountingCache(t, true)
+		_, err := cfs.Open("missing.txt")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("Bypass returns fresh handles", func(t *testing.T) {
+		cfs, counting := newCountingCache(t, false)
+		_, err := cfs.ReadFile("cached.txt")
+		require.NoError(t, err)
+		counting.files["cached.txt"] = &fstest.MapFile{Data: []byte("fresh content")}
+
+		file, err := cfs.Open("cached.txt")
+		require.NoError(t, err)
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, "fresh content", string(data))
+	})
+
+	t.Run("Stat does not read through the cache", func(t *testing.T) {
+		cfs, counting := newCountingCache(t, true)
+		info, err := fs.Stat(cfs, "cached.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(14), info.Size())
+		assert.Zero(t, counting.reads.Load())
+		assert.Empty(t, cfs.Keys())
+	})
 }
 
 func TestCachingFS_InterfaceCompliance(t *testing.T) {
@@ -540,14 +629,1088 @@ func TestFSLoader_EdgeCases(t *testing.T) {
 		})
 	})
 
-	t.Run("Context cancellation ignored", func(t *testing.T) {
+	t.Run("Context cancellation", func(t *testing.T) {
 		loader := &FSLoader{files: cachingTestFiles}
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel() // Cancel immediately
 
-		// Load should still work since context is ignored
+		// The read isn't started once the context is done
 		data, err := loader.Load(ctx, "cached.txt")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, data)
+	})
+}
+
+// countingFS records how many times Open and ReadFile reach the wrapped filesystem
+type countingFS struct {
+	files fstest.MapFS
+	reads atomic.Int64
+	opens atomic.Int64
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	c.opens.Add(1)
+	return c.files.Open(name)
+}
+
+func (c *countingFS) ReadFile(name string) ([]byte, error) {
+	c.reads.Add(1)
+	return c.files.ReadFile(name)
+}
+
+func TestCachingFS_SetEnabled(t *testing.T) {
+	t.Run("Enabled by default", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(cachingTestFiles)
 		require.NoError(t, err)
-		assert.Equal(t, []byte("cached content"), data)
+		assert.True(t, cfs.Enabled())
+	})
+
+	t.Run("Disabled cache reads through every time", func(t *testing.T) {
+		counting := &countingFS{files: fstest.MapFS{
+			"file.txt": &fstest.MapFile{Data: []byte("v1")},
+		}}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("file.txt")
+		require.NoError(t, err)
+		_, err = cfs.ReadFile("file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), counting.reads.Load())
+
+		cfs.SetEnabled(false)
+		assert.False(t, cfs.Enabled())
+		counting.files["file.txt"] = &fstest.MapFile{Data: []byte("v2")}
+		for i := 0; i < 3; i++ {
+			data, err := cfs.ReadFile("file.txt")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("v2"), data)
+		}
+		assert.Equal(t, int64(4), counting.reads.Load())
+	})
+
+	t.Run("Disabled cache passes through errors", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(cachingTestFiles)
+		require.NoError(t, err)
+		cfs.SetEnabled(false)
+
+		data, err := cfs.ReadFile("nonexistent.txt")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+		assert.Nil(t, data)
+	})
+
+	t.Run("Re-enabling clears stale entries", func(t *testing.T) {
+		counting := &countingFS{files: fstest.MapFS{
+			"file.txt": &fstest.MapFile{Data: []byte("v1")},
+		}}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+
+		data, err := cfs.ReadFile("file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+
+		cfs.SetEnabled(false)
+		counting.files["file.txt"] = &fstest.MapFile{Data: []byte("v2")}
+		cfs.SetEnabled(true)
+		assert.True(t, cfs.Enabled())
+
+		data, err = cfs.ReadFile("file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), data)
+		reads := counting.reads.Load()
+
+		data, err = cfs.ReadFile("file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), data)
+		assert.Equal(t, reads, counting.reads.Load())
+	})
+
+	t.Run("Enabling an enabled cache keeps entries", func(t *testing.T) {
+		counting := &countingFS{files: fstest.MapFS{
+			"file.txt": &fstest.MapFile{Data: []byte("v1")},
+		}}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("file.txt")
+		require.NoError(t, err)
+		cfs.SetEnabled(true)
+		_, err = cfs.ReadFile("file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+}
+
+func TestCachingFS_OnMiss(t *testing.T) {
+	var mu sync.Mutex
+	var misses []string
+	var durations []time.Duration
+	cfs, err := NewCachingFS(cachingTestFiles, &CachingFSOption{
+		OnMiss: func(filePath string, loadDuration time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			misses = append(misses, filePath)
+			durations = append(durations, loadDuration)
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("Called once per miss", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := cfs.ReadFile("cached.txt")
+			require.NoError(t, err)
+		}
+		_, err := cfs.ReadFile("test.css")
+		require.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []string{"cached.txt", "test.css"}, misses)
+		for _, d := range durations {
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+		}
+	})
+
+	t.Run("Called for failed loads", func(t *testing.T) {
+		_, err := cfs.ReadFile("nonexistent.txt")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "nonexistent.txt", misses[len(misses)-1])
+	})
+
+	t.Run("Not called while disabled", func(t *testing.T) {
+		mu.Lock()
+		count := len(misses)
+		mu.Unlock()
+
+		cfs.SetEnabled(false)
+		defer cfs.SetEnabled(true)
+		_, err := cfs.ReadFile("large.js")
+		require.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, count, len(misses))
+	})
+}
+
+func TestCachingFS_TTLFunc(t *testing.T) {
+	counting := &countingFS{files: fstest.MapFS{
+		"manifest.json":   &fstest.MapFile{Data: []byte("v1")},
+		"app.abc123.js":   &fstest.MapFile{Data: []byte("v1")},
+		"negative-ttl.js": &fstest.MapFile{Data: []byte("v1")},
+	}}
+	cfs, err := NewCachingFS(counting, &CachingFSOption{
+		TTLFunc: func(filePath string) time.Duration {
+			switch filePath {
+			case "manifest.json":
+				return 50 * time.Millisecond
+			case "negative-ttl.js":
+				return -time.Second
+			}
+			return 0
+		},
+	})
+	require.NoError(t, err)
+
+	for _, name := range []string{"manifest.json", "app.abc123.js", "negative-ttl.js"} {
+		data, err := cfs.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+		counting.files[name] = &fstest.MapFile{Data: []byte("v2")}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	t.Run("Short TTL entry is reloaded", func(t *testing.T) {
+		data, err := cfs.ReadFile("manifest.json")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), data)
+	})
+
+	t.Run("Zero TTL entry is kept", func(t *testing.T) {
+		data, err := cfs.ReadFile("app.abc123.js")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+	})
+
+	t.Run("Negative TTL entry is kept", func(t *testing.T) {
+		data, err := cfs.ReadFile("negative-ttl.js")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+	})
+}
+
+func TestCachingFS_ReadFileNS(t *testing.T) {
+	t.Run("Namespaces keep separate entries", func(t *testing.T) {
+		counting := &countingFS{files: cachingTestFiles}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			data, err := cfs.ReadFileNS("docs", "cached.txt")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("cached content"), data)
+			_, err = cfs.ReadFileNS("blog", "cached.txt")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int64(2), counting.reads.Load())
+	})
+
+	t.Run("Empty namespace shares ReadFile entries", func(t *testing.T) {
+		counting := &countingFS{files: cachingTestFiles}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("cached.txt")
+		require.NoError(t, err)
+		_, err = cfs.ReadFileNS("", "cached.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+
+	t.Run("Hooks receive the file path", func(t *testing.T) {
+		var missed []string
+		var ttlPaths []string
+		cfs, err := NewCachingFS(cachingTestFiles, &CachingFSOption{
+			OnMiss: func(filePath string, loadDuration time.Duration) {
+				missed = append(missed, filePath)
+			},
+			TTLFunc: func(filePath string) time.Duration {
+				ttlPaths = append(ttlPaths, filePath)
+				return 0
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFileNS("docs", "nested/file.js")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"nested/file.js"}, missed)
+		assert.Equal(t, []string{"nested/file.js"}, ttlPaths)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(cachingTestFiles)
+		require.NoError(t, err)
+		_, err = cfs.ReadFileNS("docs", "nonexistent.txt")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("Separator is rejected", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(cachingTestFiles)
+		require.NoError(t, err)
+		_, err = cfs.ReadFileNS("do\x00cs", "cached.txt")
+		assert.True(t, errors.Is(err, fs.ErrInvalid))
+		_, err = cfs.ReadFile("docs\x00cached.txt")
+		assert.True(t, errors.Is(err, fs.ErrInvalid))
+	})
+
+	t.Run("Servers sharing a cache", func(t *testing.T) {
+		counting := &countingFS{files: fstest.MapFS{
+			"shared/app.css": &fstest.MapFile{Data: []byte("body{}")},
+		}}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+		docs, err := NewAssetServer("/docs/", cfs)
+		require.NoError(t, err)
+		docs.FSPrefix = "shared/"
+		docs.CacheNamespace = "docs"
+		blog, err := NewAssetServer("/blog/", cfs)
+		require.NoError(t, err)
+		blog.FSPrefix = "shared/"
+		blog.CacheNamespace = "blog"
+
+		for _, target := range []struct {
+			server *AssetServer
+			path   string
+		}{{docs, "/docs/app.css"}, {blog, "/blog/app.css"}, {docs, "/docs/app.css"}} {
+			w := httptest.NewRecorder()
+			target.server.ServeHTTP(w, httptest.NewRequest("GET", target.path, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "body{}", w.Body.String())
+		}
+		assert.Equal(t, int64(2), counting.reads.Load())
+	})
+}
+
+func TestCachingFS_Variants(t *testing.T) {
+	variantFiles := fstest.MapFS{
+		"app.js":       &fstest.MapFile{Data: []byte("plain js")},
+		"app.js.br":    &fstest.MapFile{Data: []byte("brotli js")},
+		"style.css":    &fstest.MapFile{Data: []byte("plain css")},
+		"only.html.br": &fstest.MapFile{Data: []byte("brotli html")},
+	}
+	newServer := func(t *testing.T, option *CachingFSOption) (*AssetServer, *[]string) {
+		var loads []string
+		if option == nil {
+			option = &CachingFSOption{}
+		}
+		option.OnMiss = func(filePath string, loadDuration time.Duration) {
+			loads = append(loads, filePath)
+		}
+		cfs, err := NewCachingFS(variantFiles, option)
+		require.NoError(t, err)
+		server, err := NewAssetServer("/assets/", cfs)
+		require.NoError(t, err)
+		server.BrotliSuffix = ".br"
+		return server, &loads
+	}
+	get := func(server *AssetServer, path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Brotli variant is cached once", func(t *testing.T) {
+		server, loads := newServer(t, nil)
+		for i := 0; i < 3; i++ {
+			w := get(server, "/assets/app.js", "br")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "brotli js", w.Body.String())
+		}
+		assert.Equal(t, []string{"app.js.br"}, *loads)
+	})
+
+	t.Run("Negotiation still works through the cache", func(t *testing.T) {
+		server, loads := newServer(t, nil)
+		get(server, "/assets/app.js", "br")
+		w := get(server, "/assets/app.js", "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "plain js", w.Body.String())
+		w = get(server, "/assets/app.js", "br")
+		assert.Equal(t, "brotli js", w.Body.String())
+		assert.Equal(t, []string{"app.js.br", "app.js"}, *loads)
+	})
+
+	t.Run("Variant only copy is cached", func(t *testing.T) {
+		server, loads := newServer(t, nil)
+		for i := 0; i < 2; i++ {
+			w := get(server, "/assets/only.html", "gzip, br")
+			assert.Equal(t, "brotli html", w.Body.String())
+		}
+		assert.Equal(t, []string{"only.html.br"}, *loads)
+	})
+
+	t.Run("Missing variants are probed on every request by default", func(t *testing.T) {
+		server, loads := newServer(t, nil)
+		for i := 0; i < 3; i++ {
+			w := get(server, "/assets/style.css", "br")
+			assert.Equal(t, "plain css", w.Body.String())
+		}
+		assert.Equal(t, []string{"style.css.br", "style.css", "style.css.br", "style.css.br"}, *loads)
+	})
+
+	t.Run("CacheMisses remembers missing variants", func(t *testing.T) {
+		server, loads := newServer(t, &CachingFSOption{CacheMisses: true})
+		for i := 0; i < 3; i++ {
+			w := get(server, "/assets/style.css", "br")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "plain css", w.Body.String())
+		}
+		assert.Equal(t, []string{"style.css.br", "style.css"}, *loads)
+	})
+}
+
+func TestCachingFS_CacheMisses(t *testing.T) {
+	t.Run("Misses are remembered", func(t *testing.T) {
+		counting := &countingFS{files: cachingTestFiles}
+		cfs, err := NewCachingFS(counting, &CachingFSOption{CacheMisses: true})
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := cfs.ReadFile("nonexistent.txt")
+			assert.True(t, errors.Is(err, fs.ErrNotExist))
+		}
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+
+	t.Run("Misses are not remembered by default", func(t *testing.T) {
+		counting := &countingFS{files: cachingTestFiles}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := cfs.ReadFile("nonexistent.txt")
+			assert.True(t, errors.Is(err, fs.ErrNotExist))
+		}
+		assert.Equal(t, int64(3), counting.reads.Load())
+	})
+
+	t.Run("CacheMissFunc keeps variant probes out", func(t *testing.T) {
+		var loads []string
+		cfs, err := NewCachingFS(cachingTestFiles, &CachingFSOption{
+			CacheMisses: true,
+			CacheMissFunc: func(filePath string) bool {
+				return !strings.HasSuffix(filePath, ".br")
+			},
+			OnMiss: func(filePath string, loadDuration time.Duration) {
+				loads = append(loads, filePath)
+			},
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err := cfs.ReadFile("test.css.br")
+			assert.True(t, errors.Is(err, fs.ErrNotExist))
+			_, err = cfs.ReadFile("missing.css")
+			assert.True(t, errors.Is(err, fs.ErrNotExist))
+		}
+		assert.Equal(t, []string{"test.css.br", "missing.css", "test.css.br"}, loads)
+	})
+
+	t.Run("Re-enabling forgets misses", func(t *testing.T) {
+		files := fstest.MapFS{}
+		cfs, err := NewCachingFS(files, &CachingFSOption{CacheMisses: true})
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("late.txt")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+		files["late.txt"] = &fstest.MapFile{Data: []byte("arrived")}
+		_, err = cfs.ReadFile("late.txt")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+		cfs.SetEnabled(false)
+		cfs.SetEnabled(true)
+		data, err := cfs.ReadFile("late.txt")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("arrived"), data)
+	})
+}
+
+func TestCachingFS_Keys(t *testing.T) {
+	t.Run("Empty cache", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(cachingTestFiles)
+		require.NoError(t, err)
+		assert.Empty(t, cfs.Keys())
+	})
+
+	t.Run("Lists cached paths", func(t *testing.T) {
+		cfs, err := NewCachingFS(cachingTestFiles, &CachingFSOption{CacheMisses: true})
+		require.NoError(t, err)
+		for _, name := range []string{"test.css", "cached.txt", "nested/file.js", "nonexistent.txt"} {
+			cfs.ReadFile(name)
+		}
+		_, err = cfs.ReadFileNS("docs", "cached.txt")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"cached.txt", "docs:cached.txt", "nested/file.js", "test.css"}, cfs.Keys())
+	})
+
+	t.Run("Snapshot is not affected by later reads", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(cachingTestFiles)
+		require.NoError(t, err)
+		_, err = cfs.ReadFile("test.css")
+		require.NoError(t, err)
+		keys := cfs.Keys()
+
+		_, err = cfs.ReadFile("cached.txt")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test.css"}, keys)
+		assert.Len(t, cfs.Keys(), 2)
+	})
+}
+
+func TestCachingFS_Invalidate(t *testing.T) {
+	t.Run("Changed file is re-read after Invalidate", func(t *testing.T) {
+		files := fstest.MapFS{
+			"app.js":   &fstest.MapFile{Data: []byte("v1")},
+			"other.js": &fstest.MapFile{Data: []byte("other v1")},
+		}
+		cfs, err := NewDefaultCachingFS(files)
+		require.NoError(t, err)
+		_, err = cfs.ReadFile("app.js")
+		require.NoError(t, err)
+		_, err = cfs.ReadFile("other.js")
+		require.NoError(t, err)
+
+		files["app.js"] = &fstest.MapFile{Data: []byte("v2")}
+		files["other.js"] = &fstest.MapFile{Data: []byte("other v2")}
+		data, err := cfs.ReadFile("app.js")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+
+		cfs.Invalidate("app.js")
+		data, err = cfs.ReadFile("app.js")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), data)
+		data, err = cfs.ReadFile("other.js")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("other v1"), data)
+	})
+
+	t.Run("Invalidate covers every namespace", func(t *testing.T) {
+		files := fstest.MapFS{"app.js": &fstest.MapFile{Data: []byte("v1")}}
+		cfs, err := NewDefaultCachingFS(files)
+		require.NoError(t, err)
+		for _, ns := range []string{"", "docs", "blog"} {
+			_, err := cfs.ReadFileNS(ns, "app.js")
+			require.NoError(t, err)
+		}
+
+		files["app.js"] = &fstest.MapFile{Data: []byte("v2")}
+		cfs.Invalidate("app.js")
+		assert.Empty(t, cfs.Keys())
+		for _, ns := range []string{"", "docs", "blog"} {
+			data, err := cfs.ReadFileNS(ns, "app.js")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("v2"), data)
+		}
+	})
+
+	t.Run("Invalidate forgets misses", func(t *testing.T) {
+		files := fstest.MapFS{}
+		cfs, err := NewCachingFS(files, &CachingFSOption{CacheMisses: true})
+		require.NoError(t, err)
+		_, err = cfs.ReadFile("late.js")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+		files["late.js"] = &fstest.MapFile{Data: []byte("arrived")}
+		cfs.Invalidate("late.js")
+		data, err := cfs.ReadFile("late.js")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("arrived"), data)
+	})
+
+	t.Run("InvalidateAll", func(t *testing.T) {
+		counting := &countingFS{files: cachingTestFiles}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+		for _, name := range []string{"cached.txt", "test.css"} {
+			_, err := cfs.ReadFile(name)
+			require.NoError(t, err)
+		}
+
+		cfs.InvalidateAll()
+		assert.Empty(t, cfs.Keys())
+		for _, name := range []string{"cached.txt", "test.css"} {
+			_, err := cfs.ReadFile(name)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int64(4), counting.reads.Load())
+	})
+
+	t.Run("Invalidating an uncached path", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(cachingTestFiles)
+		require.NoError(t, err)
+		assert.NotPanics(t, func() { cfs.Invalidate("nonexistent.txt") })
+	})
+}
+
+func TestCachingFS_TTL(t *testing.T) {
+	t.Run("Entries are reloaded after the TTL", func(t *testing.T) {
+		files := fstest.MapFS{"feed.xml": &fstest.MapFile{Data: []byte("v1")}}
+		cfs, err := NewCachingFS(files, &CachingFSOption{TTL: 50 * time.Millisecond})
+		require.NoError(t, err)
+
+		data, err := cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+		files["feed.xml"] = &fstest.MapFile{Data: []byte("v2")}
+
+		data, err = cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+
+		time.Sleep(100 * time.Millisecond)
+		data, err = cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), data)
+	})
+
+	t.Run("Zero TTL keeps entries", func(t *testing.T) {
+		files := fstest.MapFS{"feed.xml": &fstest.MapFile{Data: []byte("v1")}}
+		cfs, err := NewCachingFS(files, &CachingFSOption{})
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		files["feed.xml"] = &fstest.MapFile{Data: []byte("v2")}
+		time.Sleep(20 * time.Millisecond)
+
+		data, err := cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+	})
+
+	t.Run("Remembered misses expire after the TTL", func(t *testing.T) {
+		files := fstest.MapFS{}
+		cfs, err := NewCachingFS(files, &CachingFSOption{TTL: 50 * time.Millisecond, CacheMisses: true})
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("feed.xml")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		files["feed.xml"] = &fstest.MapFile{Data: []byte("v1")}
+
+		_, err = cfs.ReadFile("feed.xml")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+
+		time.Sleep(100 * time.Millisecond)
+		data, err := cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+	})
+
+	t.Run("Remembered misses expire after TTLFunc", func(t *testing.T) {
+		files := fstest.MapFS{}
+		cfs, err := NewCachingFS(files, &CachingFSOption{
+			TTLFunc:     func(filePath string) time.Duration { return 50 * time.Millisecond },
+			CacheMisses: true,
+		})
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("feed.xml")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		files["feed.xml"] = &fstest.MapFile{Data: []byte("v1")}
+
+		time.Sleep(100 * time.Millisecond)
+		data, err := cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+	})
+
+	t.Run("TTLFunc takes precedence", func(t *testing.T) {
+		files := fstest.MapFS{"feed.xml": &fstest.MapFile{Data: []byte("v1")}}
+		cfs, err := NewCachingFS(files, &CachingFSOption{
+			TTL:     time.Millisecond,
+			TTLFunc: func(filePath string) time.Duration { return 0 },
+		})
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		files["feed.xml"] = &fstest.MapFile{Data: []byte("v2")}
+		time.Sleep(20 * time.Millisecond)
+
+		data, err := cfs.ReadFile("feed.xml")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), data)
+	})
+}
+
+func TestCachingFS_MaxBytes(t *testing.T) {
+	t.Run("Total size is capped", func(t *testing.T) {
+		files := fstest.MapFS{}
+		for i := range 20 {
+			files[fmt.Sprintf("file%d.bin", i)] = &fstest.MapFile{Data: make([]byte, 100)}
+		}
+		cfs, err := NewCachingFS(files, &CachingFSOption{MaxBytes: 1000})
+		require.NoError(t, err)
+
+		for i := range 20 {
+			_, err := cfs.ReadFile(fmt.Sprintf("file%d.bin", i))
+			require.NoError(t, err)
+		}
+		cfs.cache.CleanUp()
+
+		assert.Greater(t, cfs.Stats().Evictions, uint64(0))
+		assert.LessOrEqual(t, cfs.cache.WeightedSize(), uint64(1000))
+	})
+
+	t.Run("MaxEntryCount is ignored", func(t *testing.T) {
+		files := fstest.MapFS{}
+		for i := range 10 {
+			files[fmt.Sprintf("file%d.bin", i)] = &fstest.MapFile{Data: make([]byte, 10)}
+		}
+		cfs, err := NewCachingFS(files, &CachingFSOption{MaxEntryCount: 2, MaxBytes: 1 << 20})
+		require.NoError(t, err)
+
+		for i := range 10 {
+			_, err := cfs.ReadFile(fmt.Sprintf("file%d.bin", i))
+			require.NoError(t, err)
+		}
+		cfs.cache.CleanUp()
+
+		assert.Zero(t, cfs.Stats().Evictions)
+		assert.Len(t, cfs.Keys(), 10)
+	})
+
+	t.Run("Misses stay bounded by entry count", func(t *testing.T) {
+		cfs, err := NewCachingFS(fstest.MapFS{}, &CachingFSOption{MaxBytes: 1000, CacheMisses: true})
+		require.NoError(t, err)
+
+		_, err = cfs.ReadFile("missing.css")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, missing := cfs.misses.GetIfPresent("missing.css")
+		assert.True(t, missing)
+	})
+}
+
+func TestCachingFS_MaxFileSize(t *testing.T) {
+	newCounting := func() *countingFS {
+		return &countingFS{files: fstest.MapFS{
+			"small.css": &fstest.MapFile{Data: []byte("body{}")},
+			"large.mp4": &fstest.MapFile{Data: make([]byte, 1024)},
+		}}
+	}
+
+	t.Run("Large files bypass the cache", func(t *testing.T) {
+		counting := newCounting()
+		cfs, err := NewCachingFS(counting, &CachingFSOption{MaxFileSize: 512})
+		require.NoError(t, err)
+
+		for range 3 {
+			data, err := cfs.ReadFile("large.mp4")
+			require.NoError(t, err)
+			assert.Len(t, data, 1024)
+		}
+		assert.Equal(t, int64(3), counting.reads.Load())
+		assert.Empty(t, cfs.Keys())
+	})
+
+	t.Run("Small files are cached", func(t *testing.T) {
+		counting := newCounting()
+		cfs, err := NewCachingFS(counting, &CachingFSOption{MaxFileSize: 512})
+		require.NoError(t, err)
+
+		for range 3 {
+			data, err := cfs.ReadFile("small.css")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("body{}"), data)
+		}
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+
+	t.Run("No limit by default", func(t *testing.T) {
+		counting := newCounting()
+		cfs, err := NewCachingFS(counting, &CachingFSOption{})
+		require.NoError(t, err)
+
+		for range 3 {
+			_, err := cfs.ReadFile("large.mp4")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+}
+
+func TestCachingFS_Warm(t *testing.T) {
+	newWarmFS := func(t *testing.T) (*CachingFS, *countingFS) {
+		counting := &countingFS{files: fstest.MapFS{
+			"index.html":     &fstest.MapFile{Data: []byte("<html></html>")},
+			"css/site.css":   &fstest.MapFile{Data: []byte("body {}")},
+			"js/app.js":      &fstest.MapFile{Data: []byte("console.log(1)")},
+			"js/lib/util.js": &fstest.MapFile{Data: []byte("export {}")},
+		}}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+		return cfs, counting
+	}
+
+	t.Run("Warmed paths are cached", func(t *testing.T) {
+		cfs, counting := newWarmFS(t)
+		require.NoError(t, cfs.Warm(context.Background(), []string{"index.html", "js/app.js"}))
+
+		assert.Equal(t, uint64(2), cfs.Stats().LoadSuccesses)
+		assert.ElementsMatch(t, []string{"index.html", "js/app.js"}, cfs.Keys())
+
+		_, err := cfs.ReadFile("index.html")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), counting.reads.Load())
+		assert.Equal(t, uint64(1), cfs.Stats().Hits)
+	})
+
+	t.Run("Failures are aggregated", func(t *testing.T) {
+		cfs, _ := newWarmFS(t)
+		err := cfs.Warm(context.Background(), []string{"missing.txt", "index.html", "gone.css"})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+		assert.Contains(t, err.Error(), "warming missing.txt")
+		assert.Contains(t, err.Error(), "warming gone.css")
+		assert.Equal(t, []string{"index.html"}, cfs.Keys())
+	})
+
+	t.Run("Canceled context stops warming", func(t *testing.T) {
+		cfs, counting := newWarmFS(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := cfs.Warm(ctx, []string{"index.html"})
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Zero(t, counting.reads.Load())
+		assert.Empty(t, cfs.Keys())
+	})
+
+	t.Run("WarmAll caches every file", func(t *testing.T) {
+		cfs, counting := newWarmFS(t)
+		require.NoError(t, cfs.WarmAll(context.Background()))
+
+		assert.Equal(t, uint64(4), cfs.Stats().LoadSuccesses)
+		assert.ElementsMatch(t, []string{"index.html", "css/site.css", "js/app.js", "js/lib/util.js"}, cfs.Keys())
+		assert.Equal(t, int64(4), counting.reads.Load())
+	})
+
+	t.Run("WarmAll honors a canceled context", func(t *testing.T) {
+		cfs, counting := newWarmFS(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := cfs.WarmAll(ctx)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Zero(t, counting.reads.Load())
+	})
+}
+
+// blockingFS blocks ReadFile until release is closed, honoring cancellation through
+// ReadFileCtx as a network filesystem might
+type blockingFS struct {
+	fstest.MapFS
+	started chan struct{}
+	release chan struct{}
+	reads   atomic.Int64
+}
+
+func newBlockingFS(files fstest.MapFS) *blockingFS {
+	return &blockingFS{MapFS: files, started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (b *blockingFS) ReadFile(name string) ([]byte, error) {
+	return b.ReadFileCtx(context.Background(), name)
+}
+
+func (b *blockingFS) ReadFileCtx(ctx context.Context, name string) ([]byte, error) {
+	b.reads.Add(1)
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return b.MapFS.ReadFile(name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestCachingFS_ReadFileCtx(t *testing.T) {
+	files := fstest.MapFS{"slow.bin": &fstest.MapFile{Data: []byte("slow")}}
+
+	t.Run("Canceled load returns promptly", func(t *testing.T) {
+		blocking := newBlockingFS(files)
+		cfs, err := NewDefaultCachingFS(blocking)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := cfs.ReadFileCtx(ctx, "slow.bin")
+			done <- err
+		}()
+		<-blocking.started
+		cancel()
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("ReadFileCtx did not return after cancellation")
+		}
+		assert.Empty(t, cfs.Keys())
+
+		close(blocking.release)
+		data, err := cfs.ReadFileCtx(context.Background(), "slow.bin")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("slow"), data)
+	})
+
+	t.Run("Canceled context skips filesystems without ReadFileCtx", func(t *testing.T) {
+		counting := &countingFS{files: files}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = cfs.ReadFileCtx(ctx, "slow.bin")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, counting.reads.Load())
+
+		cfs.SetEnabled(false)
+		_, err = cfs.ReadFileCtx(ctx, "slow.bin")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, counting.reads.Load())
+	})
+
+	t.Run("Cached entries ignore cancellation", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(files)
+		require.NoError(t, err)
+		_, err = cfs.ReadFile("slow.bin")
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		data, err := cfs.ReadFileCtx(ctx, "slow.bin")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("slow"), data)
+	})
+
+	t.Run("Waiters retry a load canceled by another reader", func(t *testing.T) {
+		blocking := newBlockingFS(files)
+		cfs, err := NewDefaultCachingFS(blocking)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		first := make(chan error, 1)
+		go func() {
+			_, err := cfs.ReadFileCtx(ctx, "slow.bin")
+			first <- err
+		}()
+		<-blocking.started
+		second := make(chan []byte, 1)
+		go func() {
+			data, _ := cfs.ReadFileCtx(context.Background(), "slow.bin")
+			second <- data
+		}()
+		// give the second reader time to join the first's load
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		assert.ErrorIs(t, <-first, context.Canceled)
+
+		close(blocking.release)
+		select {
+		case data := <-second:
+			assert.Equal(t, []byte("slow"), data)
+		case <-time.After(time.Second):
+			t.Fatal("second reader did not finish")
+		}
+	})
+
+	t.Run("Namespaced reads", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(files)
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = cfs.ReadFileNSCtx(ctx, "site", "slow.bin")
+		assert.ErrorIs(t, err, context.Canceled)
+		data, err := cfs.ReadFileNSCtx(context.Background(), "site", "slow.bin")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("slow"), data)
+		assert.Equal(t, []string{"site:slow.bin"}, cfs.Keys())
+	})
+}
+
+// callCountingFS counts every call reaching a filesystem with symbolic link support
+type callCountingFS struct {
+	files fstest.MapFS
+	calls atomic.Int64
+}
+
+func (c *callCountingFS) Open(name string) (fs.File, error) {
+	c.calls.Add(1)
+	return c.files.Open(name)
+}
+
+func (c *callCountingFS) ReadFile(name string) ([]byte, error) {
+	c.calls.Add(1)
+	return c.files.ReadFile(name)
+}
+
+func (c *callCountingFS) Stat(name string) (fs.FileInfo, error) {
+	c.calls.Add(1)
+	return c.files.Stat(name)
+}
+
+func (c *callCountingFS) Lstat(name string) (fs.FileInfo, error) {
+	c.calls.Add(1)
+	return c.files.Lstat(name)
+}
+
+func (c *callCountingFS) ReadLink(name string) (string, error) {
+	c.calls.Add(1)
+	return c.files.ReadLink(name)
+}
+
+func TestCachingFS_WarmRequests(t *testing.T) {
+	newServer := func(t *testing.T) (*AssetServer, *callCountingFS) {
+		counting := &callCountingFS{files: fstest.MapFS{
+			"css/deep/a.css":    &fstest.MapFile{Data: []byte("a{}")},
+			"css/deep/b.css":    &fstest.MapFile{Data: []byte("b{}")},
+			"css/deep/b.css.br": &fstest.MapFile{Data: []byte("compressed")},
+		}}
+		cfs, err := NewCachingFS(counting, &CachingFSOption{CacheMisses: true})
+		require.NoError(t, err)
+		server, err := NewAssetServer("/assets/", cfs)
+		require.NoError(t, err)
+		server.BrotliSuffix = ".br"
+		server.ZstdSuffix = ".zst"
+		return server, counting
+	}
+
+	for _, requestedPath := range []string{"/assets/css/deep/a.css", "/assets/css/deep/b.css"} {
+		t.Run(requestedPath, func(t *testing.T) {
+			server, counting := newServer(t)
+			get := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest("GET", requestedPath, nil)
+				req.Header.Set("Accept-Encoding", "zstd, br")
+				w := httptest.NewRecorder()
+				server.ServeHTTP(w, req)
+				return w
+			}
+			require.Equal(t, http.StatusOK, get().Code)
+			require.NotZero(t, counting.calls.Load())
+			counting.calls.Store(0)
+
+			assert.Equal(t, http.StatusOK, get().Code)
+			assert.Zero(t, counting.calls.Load())
+		})
+	}
+}
+
+func TestCachingFS_Stat(t *testing.T) {
+	before := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	after := before.Add(time.Hour)
+
+	t.Run("Cached until invalidated", func(t *testing.T) {
+		counting := &callCountingFS{files: fstest.MapFS{"a.css": &fstest.MapFile{Data: []byte("a{}"), ModTime: before}}}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+
+		info, err := fs.Stat(cfs, "a.css")
+		require.NoError(t, err)
+		assert.Equal(t, before, info.ModTime())
+		counting.files["a.css"] = &fstest.MapFile{Data: []byte("a{}"), ModTime: after}
+		calls := counting.calls.Load()
+
+		info, err = fs.Stat(cfs, "a.css")
+		require.NoError(t, err)
+		assert.Equal(t, before, info.ModTime())
+		assert.Equal(t, calls, counting.calls.Load())
+
+		cfs.Invalidate("a.css")
+		info, err = fs.Stat(cfs, "a.css")
+		require.NoError(t, err)
+		assert.Equal(t, after, info.ModTime())
+	})
+
+	t.Run("Missing files are not cached", func(t *testing.T) {
+		files := fstest.MapFS{}
+		cfs, err := NewDefaultCachingFS(files)
+		require.NoError(t, err)
+
+		_, err = fs.Stat(cfs, "a.css")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		files["a.css"] = &fstest.MapFile{Data: []byte("a{}"), ModTime: after}
+
+		info, err := fs.Stat(cfs, "a.css")
+		require.NoError(t, err)
+		assert.Equal(t, after, info.ModTime())
+	})
+
+	t.Run("Disabled cache stats every time", func(t *testing.T) {
+		files := fstest.MapFS{"a.css": &fstest.MapFile{Data: []byte("a{}"), ModTime: before}}
+		cfs, err := NewDefaultCachingFS(files)
+		require.NoError(t, err)
+		cfs.SetEnabled(false)
+
+		_, err = fs.Stat(cfs, "a.css")
+		require.NoError(t, err)
+		files["a.css"] = &fstest.MapFile{Data: []byte("a{}"), ModTime: after}
+
+		info, err := fs.Stat(cfs, "a.css")
+		require.NoError(t, err)
+		assert.Equal(t, after, info.ModTime())
 	})
 }
@@ -0,0 +1,57 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import "net/http"
+
+// Capabilities lists the optional interfaces a http.ResponseWriter supports, directly or
+// through wrappers which implement Unwrap() http.ResponseWriter like
+// http.ResponseController expects
+type Capabilities struct {
+	// Flush reports whether buffered data can be sent to the client early (http.Flusher)
+	Flush bool
+	// Push reports whether HTTP/2 server push is available (http.Pusher). A writer may
+	// support Push yet refuse individual pushes, e.g. when the client disabled them.
+	Push bool
+	// Hijack reports whether the connection can be taken over (http.Hijacker), which
+	// only HTTP/1.x writers allow
+	Hijack bool
+}
+
+// Capabilities reports what w supports, so features depending on optional writer
+// interfaces can be checked, e.g. in tests, without type-asserting w
+func (server *AssetServer) Capabilities(w http.ResponseWriter) Capabilities {
+	_, flush := unwrapWriter[http.Flusher](w)
+	_, push := unwrapWriter[http.Pusher](w)
+	_, hijack := unwrapWriter[http.Hijacker](w)
+	return Capabilities{Flush: flush, Push: push, Hijack: hijack}
+}
+
+// unwrapWriter finds the first writer in w's Unwrap chain implementing T. Callers fall
+// back to plain writes when it returns false rather than asserting on w.
+func unwrapWriter[T any](w http.ResponseWriter) (T, bool) {
+	for w != nil {
+		if found, ok := w.(T); ok {
+			return found, true
+		}
+		wrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
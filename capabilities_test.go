@@ -0,0 +1,101 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plainWriter implements only http.ResponseWriter
+type plainWriter struct {
+	header http.Header
+}
+
+func (w *plainWriter) Header() http.Header         { return w.header }
+func (w *plainWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *plainWriter) WriteHeader(statusCode int)  {}
+
+// wrappingWriter hides the wrapped writer's interfaces except through Unwrap, like
+// typical logging or compression middleware
+type wrappingWriter struct {
+	plainWriter
+	wrapped http.ResponseWriter
+}
+
+func (w *wrappingWriter) Unwrap() http.ResponseWriter { return w.wrapped }
+
+// pushingWriter supports HTTP/2 server push
+type pushingWriter struct {
+	plainWriter
+	pushed []string
+}
+
+func (w *pushingWriter) Push(target string, opts *http.PushOptions) error {
+	w.pushed = append(w.pushed, target)
+	return nil
+}
+
+func TestCapabilities(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+
+	tests := []struct {
+		name     string
+		writer   http.ResponseWriter
+		expected Capabilities
+	}{
+		{"Plain writer", &plainWriter{header: http.Header{}}, Capabilities{}},
+		{"Recorder flushes", httptest.NewRecorder(), Capabilities{Flush: true}},
+		{"Pusher", &pushingWriter{}, Capabilities{Push: true}},
+		{"Wrapped recorder", &wrappingWriter{wrapped: httptest.NewRecorder()}, Capabilities{Flush: true}},
+		{"Doubly wrapped pusher", &wrappingWriter{wrapped: &wrappingWriter{wrapped: &pushingWriter{}}}, Capabilities{Push: true}},
+		{"Wrapper around nothing", &wrappingWriter{}, Capabilities{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, server.Capabilities(tt.writer))
+		})
+	}
+
+	t.Run("Real server connections", func(t *testing.T) {
+		var http1 Capabilities
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http1 = server.Capabilities(w)
+		}))
+		defer ts.Close()
+		resp, err := http.Get(ts.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, Capabilities{Flush: true, Hijack: true}, http1)
+	})
+}
+
+func TestUnwrapWriter(t *testing.T) {
+	pusher := &pushingWriter{}
+	found, ok := unwrapWriter[http.Pusher](&wrappingWriter{wrapped: pusher})
+	require.True(t, ok)
+	require.NoError(t, found.Push("/assets/app.css", nil))
+	assert.Equal(t, []string{"/assets/app.css"}, pusher.pushed)
+
+	_, ok = unwrapWriter[http.Pusher](nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"strings"
+)
+
+// setCORSHeaders adds Access-Control-Allow-Origin for CORSOrigin and answers OPTIONS
+// requests as CORS preflights with 204, reporting whether the request was handled
+func (server *AssetServer) setCORSHeaders(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Access-Control-Allow-Origin", server.CORSOrigin)
+	if r.Method != http.MethodOptions {
+		return false
+	}
+	methods := strings.Join(server.AllowedMethods, ", ")
+	if methods == "" {
+		// Any method is served, so whatever the preflight asks for is allowed
+		methods = r.Header.Get("Access-Control-Request-Method")
+	}
+	if methods != "" {
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		w.Header().Set("Allow", methods)
+	}
+	if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSOrigin(t *testing.T) {
+	files := fstest.MapFS{
+		"fonts/inter.woff2": &fstest.MapFile{Data: []byte("mock-woff2-data")},
+	}
+	newServer := func(t *testing.T, origin string) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.CORSOrigin = origin
+		return server
+	}
+
+	t.Run("Font request", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/fonts/inter.woff2", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		newServer(t, "https://app.example.com").ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "mock-woff2-data", w.Body.String())
+	})
+
+	t.Run("Preflight", func(t *testing.T) {
+		req := httptest.NewRequest("OPTIONS", "/assets/fonts/inter.woff2", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		req.Header.Set("Access-Control-Request-Headers", "range")
+		w := httptest.NewRecorder()
+		newServer(t, "*").ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "range", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Preflight with any method allowed", func(t *testing.T) {
+		server := newServer(t, "*")
+		server.AllowedMethods = nil
+		req := httptest.NewRequest("OPTIONS", "/assets/fonts/inter.woff2", nil)
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "PUT", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("Errors carry the header", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newServer(t, "*").ServeHTTP(w, httptest.NewRequest("GET", "/assets/fonts/missing.woff2", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Unset adds nothing", func(t *testing.T) {
+		server := newServer(t, "")
+		req := httptest.NewRequest("GET", "/assets/fonts/inter.woff2", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/assets/fonts/inter.woff2", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+}
@@ -0,0 +1,239 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// PathDiagnosis explains how an AssetServer resolves a request path
+type PathDiagnosis struct {
+	// URLPath is the request path being diagnosed, including the route
+	URLPath string `json:"urlPath"`
+	// Path is the route relative asset path after index and variant resolution
+	Path string `json:"path,omitempty"`
+	// FSPath is where Path is read from in the filesystem, including FSPrefix
+	FSPath string `json:"fsPath,omitempty"`
+	// Exists reports whether FSPath exists
+	Exists bool `json:"exists"`
+	// Variants reports, by content coding, whether precompressed variants of FSPath exist
+	Variants map[string]bool `json:"variants,omitempty"`
+	// MimeType is the inferred type, and Typer the pattern which matched to infer it
+	MimeType string `json:"mimeType,omitempty"`
+	Typer    string `json:"typer,omitempty"`
+	// Blocked names the rule which stops the path being served from the filesystem
+	Blocked string `json:"blocked,omitempty"`
+	// Location is where RedirectFunc redirects the request
+	Location string `json:"location,omitempty"`
+	// Fallback is the SPAFallback file served in place of a missing Path
+	Fallback string `json:"fallback,omitempty"`
+	// Encoding is the content coding negotiated with the diagnosing request's headers
+	Encoding string `json:"encoding,omitempty"`
+	// Status is the status code the request would receive
+	Status int `json:"status"`
+	// Error is the error reading the asset, if any
+	Error string `json:"error,omitempty"`
+}
+
+// DebugHandler returns a handler which, for "?path=/route/file.css", responds with a JSON
+// PathDiagnosis describing how the server resolves that request path. Accept-Encoding
+// and Save-Data on the diagnosing request are used for negotiation; language and bot
+// handling are not applied. It exposes the server's configuration, so only mount it on
+// development or otherwise protected routes.
+func (server *AssetServer) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urlPath := r.URL.Query().Get("path")
+		if urlPath == "" {
+			http.Error(w, "missing path parameter", http.StatusBadRequest)
+			return
+		}
+		body, err := json.MarshalIndent(server.diagnose(r, urlPath), "", "  ")
+		if err != nil {
+			server.fail(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", mimeTypeJSON)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(body)
+	})
+}
+
+// diagnose mirrors serve without writing a response
+func (server *AssetServer) diagnose(r *http.Request, urlPath string) PathDiagnosis {
+	diagnosis := PathDiagnosis{URLPath: urlPath}
+	requestedPath := server.routePath(urlPath)
+	if malformedPath(&url.URL{Path: urlPath}) {
+		diagnosis.Blocked = "malformed path"
+		diagnosis.Status = http.StatusBadRequest
+		return diagnosis
+	}
+	if escapesRoot(requestedPath) {
+		return blocked(diagnosis, "path traversal")
+	}
+	if server.Maintenance != nil {
+		exempt := server.Maintenance.Exempt
+		if exempt == nil || !exempt.MatchString(requestedPath) {
+			diagnosis.Blocked = "maintenance mode"
+			diagnosis.Status = http.StatusServiceUnavailable
+			return diagnosis
+		}
+	}
+	if server.StrictFilenames && !portablePath(requestedPath) {
+		return blocked(diagnosis, "non-portable filename")
+	}
+	if server.HideDotfiles && hiddenPath(requestedPath) {
+		return blocked(diagnosis, "hidden dotfile")
+	}
+	if server.RedirectFunc != nil {
+		redirected := r.Clone(r.Context())
+		redirected.URL = &url.URL{Path: urlPath}
+		if location, status, ok := server.redirect(redirected); ok {
+			diagnosis.Location = location
+			diagnosis.Status = status
+			return diagnosis
+		}
+	}
+	if requestedPath == "" || strings.HasSuffix(requestedPath, "/") {
+		if server.IndexFile == "" {
+			return blocked(diagnosis, "directory without IndexFile")
+		}
+		requestedPath += server.IndexFile
+	}
+	if original, ok := server.disabledVariant(requestedPath); ok {
+		switch server.DisabledVariants {
+		case DisabledVariantNotFound:
+			diagnosis.Path = requestedPath
+			return blocked(diagnosis, "disabled variant")
+		case DisabledVariantOriginal:
+			requestedPath = original
+		}
+	}
+	server.describe(&diagnosis, requestedPath)
+	data, encoding, err := server.readAsset(r, requestedPath)
+	if errors.Is(err, fs.ErrNotExist) && server.SPAFallback != "" && !server.hasKnownExtension(requestedPath) {
+		diagnosis.Fallback = server.SPAFallback
+		server.describe(&diagnosis, server.SPAFallback)
+		data, encoding, err = server.readAsset(r, server.SPAFallback)
+	}
+	if err != nil {
+		diagnosis.Error = err.Error()
+		diagnosis.Status = errorStatus(err)
+		return diagnosis
+	}
+	if encoding == "" && server.gzipAllowed(r, diagnosis.MimeType, data) {
+		encoding = gzipEncoding
+	}
+	diagnosis.Encoding = encoding
+	diagnosis.Status = http.StatusOK
+	return diagnosis
+}
+
+// describe fills in where requestedPath is read from and how its type is inferred
+func (server *AssetServer) describe(diagnosis *PathDiagnosis, requestedPath string) {
+	diagnosis.Path = requestedPath
+	diagnosis.FSPath = server.fsPath(requestedPath)
+	_, err := fs.Stat(server.files, diagnosis.FSPath)
+	diagnosis.Exists = err == nil
+	diagnosis.Variants = nil
+	for _, encoding := range server.variantEncodings() {
+		if diagnosis.Variants == nil {
+			diagnosis.Variants = make(map[string]bool)
+		}
+		_, err := fs.Stat(server.files, diagnosis.FSPath+server.variantSuffix(encoding))
+		diagnosis.Variants[encoding] = err == nil
+	}
+	diagnosis.MimeType = server.inferMimeType(requestedPath)
+	diagnosis.Typer = ""
+	base := server.variantBase(requestedPath)
+	for _, typer := range server.typers {
+		if typer.expr.MatchString(base) {
+			diagnosis.Typer = typer.expr.String()
+			break
+		}
+	}
+}
+
+// blocked records that rule stops the request with a 404
+func blocked(diagnosis PathDiagnosis, rule string) PathDiagnosis {
+	diagnosis.Blocked = rule
+	diagnosis.Status = http.StatusNotFound
+	return diagnosis
+}
+
+// Describe returns a human-readable summary of the server's configuration: its route,
+// filesystem prefix, variant suffixes, which handler funcs are set, and the registered
+// mime types, Cache-Control rules, and dispositions in match order, since the first
+// match wins. Like DebugHandler it reveals configuration, so keep it out of responses.
+func (server *AssetServer) Describe() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "route: %s\n", server.route)
+	fmt.Fprintf(&b, "fs prefix: %s\n", describeValue(server.FSPrefix))
+	fmt.Fprintf(&b, "brotli suffix: %s\n", describeValue(server.BrotliSuffix))
+	fmt.Fprintf(&b, "zstd suffix: %s\n", describeValue(server.ZstdSuffix))
+	defaultMimeType := server.defaultMimeType
+	if defaultMimeType == "" {
+		defaultMimeType = mimeTypeUnknown
+	}
+	fmt.Fprintf(&b, "default mime type: %s\n", defaultMimeType)
+	fmt.Fprintf(&b, "err func: %s\n", describeFunc(server.ErrFunc, DefaultErrFunc))
+	fmt.Fprintf(&b, "header func: %s\n", describeFunc(server.HeaderFunc, DefaultHeaderFunc))
+	fmt.Fprintf(&b, "asset header func: %s\n", describeFunc(server.AssetHeaderFunc, nil))
+	fmt.Fprintf(&b, "error log func: %s\n", describeFunc(server.ErrorLogFunc, nil))
+	fmt.Fprintf(&b, "mime types (%d):\n", len(server.typers))
+	for i, typer := range server.typers {
+		fmt.Fprintf(&b, "  %d. %s -> %s\n", i+1, typer.expr, typer.mimeType)
+	}
+	if len(server.cacheControls) > 0 {
+		fmt.Fprintf(&b, "cache-control rules (%d):\n", len(server.cacheControls))
+		for i, rule := range server.cacheControls {
+			fmt.Fprintf(&b, "  %d. %s -> %s\n", i+1, rule.expr, rule.value)
+		}
+	}
+	if len(server.dispositions) > 0 {
+		fmt.Fprintf(&b, "dispositions (%d):\n", len(server.dispositions))
+		for i, rule := range server.dispositions {
+			fmt.Fprintf(&b, "  %d. %s -> %s\n", i+1, rule.expr, rule.disposition)
+		}
+	}
+	return b.String()
+}
+
+// describeValue returns value, or "(none)" when it is empty
+func describeValue(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// describeFunc reports whether fn is unset, the package's default implementation, or a
+// custom one. Funcs can only be compared to nil in Go, so their code pointers are used.
+func describeFunc[F any](fn F, defaultFn F) string {
+	value := reflect.ValueOf(fn)
+	if value.IsNil() {
+		return "none"
+	}
+	if defaultValue := reflect.ValueOf(defaultFn); !defaultValue.IsNil() && value.Pointer() == defaultValue.Pointer() {
+		return "default"
+	}
+	return "custom"
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandler(t *testing.T) {
+	files := fstest.MapFS{
+		"static/css/site.css":    &fstest.MapFile{Data: []byte("body{}")},
+		"static/css/site.css.br": &fstest.MapFile{Data: []byte("compressed")},
+		"static/index.html":      &fstest.MapFile{Data: []byte("<h1>home</h1>")},
+		"static/nul.txt":         &fstest.MapFile{Data: []byte("reserved")},
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.FSPrefix = "static/"
+		server.BrotliSuffix = ".br"
+		return server
+	}
+	diagnose := func(t *testing.T, server *AssetServer, p string, acceptEncoding string) PathDiagnosis {
+		req := httptest.NewRequest("GET", "/debug?path="+url.QueryEscape(p), nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		server.DebugHandler().ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, mimeTypeJSON, w.Header().Get("Content-Type"))
+		var diagnosis PathDiagnosis
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diagnosis))
+		return diagnosis
+	}
+
+	t.Run("Existing asset with a variant", func(t *testing.T) {
+		diagnosis := diagnose(t, newServer(t), "/assets/css/site.css", "br")
+
+		assert.Equal(t, PathDiagnosis{
+			URLPath:  "/assets/css/site.css",
+			Path:     "css/site.css",
+			FSPath:   "static/css/site.css",
+			Exists:   true,
+			Variants: map[string]bool{brotliEncoding: true},
+			MimeType: utf8Type(mimeTypeCSS),
+			Typer:    cssRegex.String(),
+			Encoding: brotliEncoding,
+			Status:   http.StatusOK,
+		}, diagnosis)
+	})
+
+	t.Run("Encoding follows the diagnosing request", func(t *testing.T) {
+		diagnosis := diagnose(t, newServer(t), "/assets/css/site.css", "")
+
+		assert.Empty(t, diagnosis.Encoding)
+		assert.Equal(t, http.StatusOK, diagnosis.Status)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		diagnosis := diagnose(t, newServer(t), "/assets/css/missing.css", "")
+
+		assert.Equal(t, "static/css/missing.css", diagnosis.FSPath)
+		assert.False(t, diagnosis.Exists)
+		assert.Equal(t, map[string]bool{brotliEncoding: false}, diagnosis.Variants)
+		assert.Equal(t, http.StatusNotFound, diagnosis.Status)
+		assert.NotEmpty(t, diagnosis.Error)
+	})
+
+	t.Run("Unmatched type", func(t *testing.T) {
+		diagnosis := diagnose(t, newServer(t), "/assets/data.bin", "")
+
+		assert.Equal(t, mimeTypeUnknown, diagnosis.MimeType)
+		assert.Empty(t, diagnosis.Typer)
+	})
+
+	t.Run("Index resolution", func(t *testing.T) {
+		server := newServer(t)
+		server.IndexFile = "index.html"
+		diagnosis := diagnose(t, server, "/assets/", "")
+
+		assert.Equal(t, "index.html", diagnosis.Path)
+		assert.True(t, diagnosis.Exists)
+		assert.Equal(t, http.StatusOK, diagnosis.Status)
+	})
+
+	t.Run("Directory without IndexFile", func(t *testing.T) {
+		diagnosis := diagnose(t, newServer(t), "/assets/css/", "")
+
+		assert.Equal(t, "directory without IndexFile", diagnosis.Blocked)
+		assert.Equal(t, http.StatusNotFound, diagnosis.Status)
+	})
+
+	t.Run("Strict filenames", func(t *testing.T) {
+		server := newServer(t)
+		server.StrictFilenames = true
+		diagnosis := diagnose(t, server, "/assets/nul.txt", "")
+
+		assert.Equal(t, "non-portable filename", diagnosis.Blocked)
+		assert.Equal(t, http.StatusNotFound, diagnosis.Status)
+	})
+
+	t.Run("Hidden dotfile", func(t *testing.T) {
+		server := newServer(t)
+		server.HideDotfiles = true
+		diagnosis := diagnose(t, server, "/assets/.env", "")
+
+		assert.Equal(t, "hidden dotfile", diagnosis.Blocked)
+		assert.Equal(t, http.StatusNotFound, diagnosis.Status)
+	})
+
+	t.Run("Path traversal", func(t *testing.T) {
+		diagnosis := diagnose(t, newServer(t), "/assets/../../etc/passwd", "")
+
+		assert.Equal(t, "path traversal", diagnosis.Blocked)
+		assert.Equal(t, http.StatusNotFound, diagnosis.Status)
+		assert.Empty(t, diagnosis.FSPath)
+	})
+
+	t.Run("Malformed path", func(t *testing.T) {
+		diagnosis := diagnose(t, newServer(t), "/assets/app.js\x00.txt", "")
+
+		assert.Equal(t, "malformed path", diagnosis.Blocked)
+		assert.Equal(t, http.StatusBadRequest, diagnosis.Status)
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		server := newServer(t)
+		server.RedirectFunc = func(r *http.Request) (string, int, bool) {
+			return "/assets/css/site.css", http.StatusFound, r.URL.Path == "/assets/old.css"
+		}
+		diagnosis := diagnose(t, server, "/assets/old.css", "")
+
+		assert.Equal(t, "/assets/css/site.css", diagnosis.Location)
+		assert.Equal(t, http.StatusFound, diagnosis.Status)
+		assert.Empty(t, diagnose(t, server, "/assets/css/site.css", "").Location)
+	})
+
+	t.Run("Maintenance mode", func(t *testing.T) {
+		server := newServer(t)
+		server.Maintenance = &MaintenanceConfig{File: "index.html", Exempt: regexp.MustCompile(`^css/`)}
+
+		assert.Equal(t, "maintenance mode", diagnose(t, server, "/assets/app.js", "").Blocked)
+		assert.Empty(t, diagnose(t, server, "/assets/css/site.css", "").Blocked)
+	})
+
+	t.Run("SPA fallback", func(t *testing.T) {
+		server := newServer(t)
+		server.SPAFallback = "index.html"
+		diagnosis := diagnose(t, server, "/assets/users/42", "")
+
+		assert.Equal(t, "index.html", diagnosis.Fallback)
+		assert.Equal(t, "index.html", diagnosis.Path)
+		assert.Equal(t, http.StatusOK, diagnosis.Status)
+	})
+
+	t.Run("Missing path parameter", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newServer(t).DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestDescribe(t *testing.T) {
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		return server
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		summary := newServer(t).Describe()
+
+		assert.Contains(t, summary, "route: /assets/\n")
+		assert.Contains(t, summary, "fs prefix: (none)\n")
+		assert.Contains(t, summary, "brotli suffix: (none)\n")
+		assert.Contains(t, summary, "default mime type: application/octet-stream\n")
+		assert.Contains(t, summary, "err func: default\n")
+		assert.Contains(t, summary, "header func: none\n")
+		assert.Contains(t, summary, "asset header func: none\n")
+		assert.NotContains(t, summary, "cache-control rules")
+		assert.NotContains(t, summary, "dispositions")
+	})
+
+	t.Run("Configuration", func(t *testing.T) {
+		server := newServer(t)
+		server.FSPrefix = "dist/"
+		server.BrotliSuffix = ".br"
+		server.ErrFunc = ContentNegotiatedErrFunc
+		server.HeaderFunc = DefaultHeaderFunc
+		server.AssetHeaderFunc = ImmutableHeaderFunc(regexp.MustCompile(`\.js$`))
+		server.SetDefaultMimeType("text/plain")
+		server.RegisterCacheControl(regexp.MustCompile(`\.html$`), "no-cache")
+		server.RegisterDisposition(regexp.MustCompile(`\.pdf$`), "attachment")
+		summary := server.Describe()
+
+		assert.Contains(t, summary, "fs prefix: dist/\n")
+		assert.Contains(t, summary, "brotli suffix: .br\n")
+		assert.Contains(t, summary, "default mime type: text/plain\n")
+		assert.Contains(t, summary, "err func: custom\n")
+		assert.Contains(t, summary, "header func: default\n")
+		assert.Contains(t, summary, "asset header func: custom\n")
+		assert.Contains(t, summary, "cache-control rules (1):\n  1. \\.html$ -> no-cache\n")
+		assert.Contains(t, summary, "dispositions (1):\n  1. \\.pdf$ -> attachment\n")
+	})
+
+	t.Run("Mime types in match order", func(t *testing.T) {
+		server := newServer(t)
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.special\.css$`), "text/x-special", true))
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.last$`), "application/x-last", false))
+		summary := server.Describe()
+
+		mappings := server.ListMimeTypes()
+		assert.Contains(t, summary, fmt.Sprintf("mime types (%d):\n", len(mappings)))
+		previous := -1
+		for i, mapping := range mappings {
+			line := fmt.Sprintf("  %d. %s -> %s\n", i+1, mapping.Expr, mapping.MimeType)
+			index := strings.Index(summary, line)
+			require.GreaterOrEqual(t, index, 0, line)
+			assert.Greater(t, index, previous, line)
+			previous = index
+		}
+		assert.Contains(t, summary, "  1. \\.special\\.css$ -> text/x-special\n")
+		assert.True(t, strings.HasSuffix(summary, fmt.Sprintf("  %d. \\.last$ -> application/x-last\n", len(mappings))))
+	})
+}
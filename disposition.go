@@ -0,0 +1,60 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+)
+
+// dispositionRule sends disposition as Content-Disposition for paths matching expr
+type dispositionRule struct {
+	expr        *regexp.Regexp
+	disposition string
+}
+
+// RegisterDisposition sends Content-Disposition for assets whose route-relative path
+// matches expr, e.g. `\.(pdf|zip)$` with "attachment" to have browsers download them.
+// The asset's base name is added as the suggested filename. Rules are matched in
+// registration order and the first match wins; paths matching none get no header.
+// Returns false when expr is nil or disposition is empty.
+// This method is not safe for concurrent use with other configuration
+// methods or with ServeHTTP. Configure the server before serving requests
+func (server *AssetServer) RegisterDisposition(expr *regexp.Regexp, disposition string) bool {
+	if expr == nil || disposition == "" {
+		return false
+	}
+	server.dispositions = append(server.dispositions, dispositionRule{expr: expr, disposition: disposition})
+	return true
+}
+
+// setDisposition adds Content-Disposition when a registered rule matches requestedPath.
+// Direct requests for precompressed variants are named after the original file.
+func (server *AssetServer) setDisposition(w http.ResponseWriter, requestedPath string) {
+	requestedPath = server.variantBase(requestedPath)
+	for _, rule := range server.dispositions {
+		if rule.expr.MatchString(requestedPath) {
+			// FormatMediaType quotes the filename, switching to RFC 2231 for non-ASCII names
+			header := mime.FormatMediaType(rule.disposition, map[string]string{"filename": path.Base(requestedPath)})
+			if header == "" {
+				header = rule.disposition
+			}
+			w.Header().Set("Content-Disposition", header)
+			return
+		}
+	}
+}
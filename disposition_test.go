@@ -0,0 +1,90 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDisposition(t *testing.T) {
+	files := fstest.MapFS{
+		"downloads/release.zip": &fstest.MapFile{Data: []byte("PK")},
+		"docs/manual.pdf":       &fstest.MapFile{Data: []byte("%PDF")},
+		"docs/résumé.pdf":       &fstest.MapFile{Data: []byte("%PDF")},
+		"site.css":              &fstest.MapFile{Data: []byte("body {}")},
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		require.True(t, server.RegisterDisposition(regexp.MustCompile(`^docs/manual\.pdf$`), "inline"))
+		require.True(t, server.RegisterDisposition(regexp.MustCompile(`\.(zip|pdf)$`), "attachment"))
+		return server
+	}
+	get := func(server *AssetServer, urlPath string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", urlPath, nil))
+		return w
+	}
+
+	t.Run("Zip is an attachment", func(t *testing.T) {
+		w := get(newServer(t), "/assets/downloads/release.zip")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `attachment; filename=release.zip`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("CSS gets no header", func(t *testing.T) {
+		w := get(newServer(t), "/assets/site.css")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Values("Content-Disposition"))
+	})
+
+	t.Run("First match wins", func(t *testing.T) {
+		w := get(newServer(t), "/assets/docs/manual.pdf")
+
+		assert.Equal(t, `inline; filename=manual.pdf`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Non-ASCII filenames", func(t *testing.T) {
+		w := get(newServer(t), "/assets/docs/r%C3%A9sum%C3%A9.pdf")
+
+		assert.Equal(t, `attachment; filename*=utf-8''r%C3%A9sum%C3%A9.pdf`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Streamed files", func(t *testing.T) {
+		server := newServer(t)
+		server.StreamThreshold = 1
+		w := get(server, "/assets/downloads/release.zip")
+
+		assert.Equal(t, `attachment; filename=release.zip`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Invalid rules refused", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+
+		assert.False(t, server.RegisterDisposition(nil, "attachment"))
+		assert.False(t, server.RegisterDisposition(regexp.MustCompile(`\.zip$`), ""))
+		assert.Empty(t, get(server, "/assets/downloads/release.zip").Header().Get("Content-Disposition"))
+	})
+}
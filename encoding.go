@@ -0,0 +1,213 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	identityEncoding = "identity"
+	gzipEncoding     = "gzip"
+	zstdEncoding     = "zstd"
+)
+
+// Conventional file suffixes of precompressed variants
+const (
+	brotliSuffix = ".br"
+	gzipSuffix   = ".gz"
+	zstdSuffix   = ".zst"
+)
+
+// parseAcceptEncoding parses an Accept-Encoding header into a map of
+// lowercased content codings to their quality values. Codings without
+// an explicit q parameter default to 1.
+func parseAcceptEncoding(header string) map[string]float64 {
+	return parseQualityValues(header)
+}
+
+// parseQualityValues parses a comma separated header of tokens with optional
+// q parameters, as used by Accept, Accept-Encoding, and Accept-Language
+func parseQualityValues(header string) map[string]float64 {
+	codings := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.ToLower(strings.TrimSpace(key)) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			quality = q
+		}
+		codings[name] = quality
+	}
+	return codings
+}
+
+// identityRefused reports whether an Accept-Encoding header forbids the
+// uncompressed representation, either via "identity;q=0" or via "*;q=0"
+// without an explicit identity entry.
+func identityRefused(header string) bool {
+	return encodingRefused(header, identityEncoding)
+}
+
+// encodingRefused reports whether an Accept-Encoding header explicitly forbids coding,
+// either via "coding;q=0" or via "*;q=0" without an entry for coding
+func encodingRefused(header, coding string) bool {
+	codings := parseAcceptEncoding(header)
+	if q, ok := codings[coding]; ok {
+		return q == 0
+	}
+	if q, ok := codings["*"]; ok {
+		return q == 0
+	}
+	return false
+}
+
+// identityOnly reports whether an Accept-Encoding header accepts nothing but the
+// uncompressed representation, e.g. "identity" from a proxy which compresses itself
+func identityOnly(header string) bool {
+	codings := parseAcceptEncoding(header)
+	if codings[identityEncoding] == 0 {
+		return false
+	}
+	for coding, q := range codings {
+		if coding != identityEncoding && q > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header gives coding a non-zero
+// quality value, either in its own entry or through "*"
+func acceptsEncoding(header, coding string) bool {
+	return encodingQuality(parseAcceptEncoding(header), coding) > 0
+}
+
+// encodingQuality returns the quality value parsed Accept-Encoding codings give coding,
+// falling back to a "*" entry when coding isn't listed. Codings matching neither get 0.
+func encodingQuality(codings map[string]float64, coding string) float64 {
+	if q, ok := codings[coding]; ok {
+		return q
+	}
+	return codings["*"]
+}
+
+// implicitIdentityQuality is the quality value of identity when Accept-Encoding lists
+// neither it nor "*": still acceptable, but ranked below every coding the client names
+const implicitIdentityQuality = math.SmallestNonzeroFloat64
+
+// preferredEncodings returns the codings of supported which an Accept-Encoding header
+// accepts, best first by quality value. Ties keep their order in supported, which is
+// the server's preference.
+func preferredEncodings(header string, supported []string) []string {
+	codings := parseAcceptEncoding(header)
+	quality := func(coding string) float64 {
+		_, listed := codings[coding]
+		_, wildcard := codings["*"]
+		if coding == identityEncoding && !listed && !wildcard {
+			return implicitIdentityQuality
+		}
+		return encodingQuality(codings, coding)
+	}
+	var accepted []string
+	for _, coding := range supported {
+		if quality(coding) > 0 {
+			accepted = append(accepted, coding)
+		}
+	}
+	slices.SortStableFunc(accepted, func(a, b string) int {
+		return cmp.Compare(quality(b), quality(a))
+	})
+	return accepted
+}
+
+// decodeBrotli decompresses Brotli encoded data
+func decodeBrotli(data []byte) ([]byte, error) {
+	decoded, err := io.ReadAll(brotli.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decoding brotli variant: %w", err)
+	}
+	return decoded, nil
+}
+
+// compressibleMimeTypes lists non-text types which benefit from compression
+var compressibleMimeTypes = map[string]bool{
+	"application/wasm": true,
+	"image/svg+xml":    true,
+}
+
+// textMimeTypes lists types outside text/ whose content is text
+var textMimeTypes = map[string]bool{
+	"application/json":       true,
+	"application/javascript": true,
+	"application/xml":        true,
+}
+
+// textMimeType reports whether content of mimeType is text, and so has a charset
+func textMimeType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	return strings.HasPrefix(mimeType, "text/") || strings.HasSuffix(mimeType, "+json") ||
+		strings.HasSuffix(mimeType, "+xml") || textMimeTypes[mimeType]
+}
+
+// compressibleMimeType reports whether content of mimeType is worth compressing. Images,
+// fonts, and archives are usually compressed already.
+func compressibleMimeType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	return textMimeType(mimeType) || compressibleMimeTypes[mimeType]
+}
+
+// gzipCompress compresses data at the given compress/gzip level
+func gzipCompress(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
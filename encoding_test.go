@@ -0,0 +1,772 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAcceptEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected map[string]float64
+	}{
+		{"Empty header", "", map[string]float64{}},
+		{"Single coding", "br", map[string]float64{"br": 1}},
+		{"Multiple codings", "gzip, br", map[string]float64{"gzip": 1, "br": 1}},
+		{"Quality values", "gzip;q=0.5, br;q=1.0", map[string]float64{"gzip": 0.5, "br": 1}},
+		{"Mixed case and spacing", " GZip ; Q=0.2 ,BR", map[string]float64{"gzip": 0.2, "br": 1}},
+		{"Invalid quality", "gzip;q=abc", map[string]float64{"gzip": 0}},
+		{"Identity refused", "br, identity;q=0", map[string]float64{"br": 1, "identity": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseAcceptEncoding(tt.header))
+		})
+	}
+}
+
+func TestIdentityRefused(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected bool
+	}{
+		{"Empty header", "", false},
+		{"Identity allowed implicitly", "br", false},
+		{"Identity refused", "br, identity;q=0", true},
+		{"Identity refused with decimal", "identity;q=0.0", true},
+		{"Identity low but allowed", "identity;q=0.1", false},
+		{"Wildcard refused", "br, *;q=0", true},
+		{"Wildcard refused but identity allowed", "*;q=0, identity", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, identityRefused(tt.header))
+		})
+	}
+}
+
+func TestEncodingRefused(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected bool
+	}{
+		{"Empty header", "", false},
+		{"Not listed", "gzip", false},
+		{"Accepted", "br", false},
+		{"Zero quality", "gzip, br;q=0", true},
+		{"Wildcard zero", "*;q=0", true},
+		{"Explicit entry overrides wildcard", "br;q=0.5, *;q=0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, encodingRefused(tt.header, brotliEncoding))
+		})
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected bool
+	}{
+		{"Empty header", "", false},
+		{"Listed", "gzip, br", true},
+		{"Not listed", "gzip", false},
+		{"Zero quality", "br;q=0", false},
+		{"Low quality", "br;q=0.001", true},
+		{"Wildcard", "*", true},
+		{"Wildcard with quality", "gzip, *;q=0.2", true},
+		{"Wildcard refused", "*;q=0", false},
+		{"Explicit zero overrides wildcard", "br;q=0, *", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, acceptsEncoding(tt.header, brotliEncoding))
+		})
+	}
+}
+
+func TestPreferredEncodings(t *testing.T) {
+	supported := []string{zstdEncoding, brotliEncoding, gzipEncoding}
+	tests := []struct {
+		name     string
+		header   string
+		expected []string
+	}{
+		{"Empty header", "", nil},
+		{"Server order on ties", "gzip, br, zstd", []string{zstdEncoding, brotliEncoding, gzipEncoding}},
+		{"Ordered by quality", "gzip;q=0.5, br;q=1.0", []string{brotliEncoding, gzipEncoding}},
+		{"Quality beats server order", "zstd;q=0.3, br;q=0.8, gzip;q=0.5", []string{brotliEncoding, gzipEncoding, zstdEncoding}},
+		{"Zero quality disables", "zstd;q=0, br, gzip;q=0", []string{brotliEncoding}},
+		{"Unsupported codings ignored", "deflate, compress, br;q=0.1", []string{brotliEncoding}},
+		{"Wildcard", "*", []string{zstdEncoding, brotliEncoding, gzipEncoding}},
+		{"Wildcard below explicit", "*;q=0.1, gzip", []string{gzipEncoding, zstdEncoding, brotliEncoding}},
+		{"Wildcard with exclusion", "*, zstd;q=0", []string{brotliEncoding, gzipEncoding}},
+		{"Wildcard refused", "*;q=0", nil},
+		{"Identity refusal accepts nothing", "identity;q=0", nil},
+		{"Invalid quality disables", "br;q=2, gzip", []string{gzipEncoding}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, preferredEncodings(tt.header, supported))
+		})
+	}
+
+	withIdentity := append(slices.Clone(supported), identityEncoding)
+	identityTests := []struct {
+		name     string
+		header   string
+		expected []string
+	}{
+		{"Unlisted identity ranks last", "br;q=0.1", []string{brotliEncoding, identityEncoding}},
+		{"Listed identity ranks by quality", "identity, br;q=0.1", []string{identityEncoding, brotliEncoding}},
+		{"Wildcard covers identity", "*;q=0.5, br", []string{brotliEncoding, zstdEncoding, gzipEncoding, identityEncoding}},
+		{"Refused identity", "br, identity;q=0", []string{brotliEncoding}},
+		{"Empty header accepts identity", "", []string{identityEncoding}},
+	}
+	for _, tt := range identityTests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, preferredEncodings(tt.header, withIdentity))
+		})
+	}
+}
+
+func TestQualityValueNegotiation(t *testing.T) {
+	files := fstest.MapFS{
+		"app.js":     &fstest.MapFile{Data: []byte("console.log('original');")},
+		"app.js.br":  &fstest.MapFile{Data: []byte("brotli")},
+		"app.js.zst": &fstest.MapFile{Data: []byte("zstd")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.BrotliSuffix = ".br"
+	server.ZstdSuffix = ".zst"
+
+	tests := []struct {
+		acceptEncoding   string
+		expectedEncoding string
+		expectedBody     string
+	}{
+		{"br, zstd", "zstd", "zstd"},
+		{"zstd;q=0.5, br;q=1.0", "br", "brotli"},
+		{"br;q=0.2, zstd;q=0.9", "zstd", "zstd"},
+		{"zstd;q=0, br", "br", "brotli"},
+		{"br;q=0, zstd;q=0", "", "console.log('original');"},
+		{"*", "zstd", "zstd"},
+		{"*;q=0.5, br", "br", "brotli"},
+		{"gzip", "", "console.log('original');"},
+		{"identity, br;q=0.1", "", "console.log('original');"},
+		{"identity;q=0.5, br", "br", "brotli"},
+		{"br;q=0.1", "br", "brotli"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/assets/app.js", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedEncoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestGzipQualityNegotiation(t *testing.T) {
+	files := fstest.MapFS{
+		"app.js":      &fstest.MapFile{Data: []byte("console.log('original');")},
+		"app.js.br":   &fstest.MapFile{Data: []byte("brotli")},
+		"logo.png":    &fstest.MapFile{Data: []byte("png")},
+		"logo.png.br": &fstest.MapFile{Data: []byte("brotli png")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.BrotliSuffix = ".br"
+	server.EnableGzip = true
+	server.GzipMinSize = 0
+
+	tests := []struct {
+		requestedPath    string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{"app.js", "gzip;q=1, br;q=0.1", "gzip"},
+		{"app.js", "gzip;q=0.1, br", "br"},
+		{"app.js", "gzip, br", "br"},
+		{"app.js", "identity, br;q=0.1", ""},
+		// gzip never applies to incompressible types, so it can't displace their variants
+		{"logo.png", "gzip;q=1, br;q=0.1", "br"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requestedPath+" "+tt.acceptEncoding, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/assets/"+tt.requestedPath, nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedEncoding, w.Header().Get("Content-Encoding"))
+		})
+	}
+}
+
+func TestIdentityRefusal(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+	server.BrotliSuffix = ".br"
+
+	t.Run("Uncompressed only returns 406", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.txt", nil)
+		req.Header.Set("Accept-Encoding", "br, identity;q=0")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("Compressed variant is still served", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "br, identity;q=0")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "compressed-css-data", w.Body.String())
+	})
+
+	t.Run("Identity allowed serves uncompressed", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.txt", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "plain text", w.Body.String())
+	})
+}
+
+func TestCompressionUADenyList(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+	server.BrotliSuffix = ".br"
+	server.CompressionUADenyList = []*regexp.Regexp{regexp.MustCompile(`MSIE [1-6]\.`)}
+
+	t.Run("Denied user agent is served uncompressed", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		req.Header.Set("User-Agent", "Mozilla/4.0 (compatible; MSIE 6.0; Windows NT 5.1)")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "body { color: blue; }", w.Body.String())
+	})
+
+	t.Run("Other user agents get the compressed variant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) Firefox/130.0")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "compressed-css-data", w.Body.String())
+	})
+
+	t.Run("Only compressed variant returns 404 for denied user agent", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/only-brotli.js", nil)
+		req.Header.Set("User-Agent", "Mozilla/4.0 (compatible; MSIE 5.5; Windows 98)")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSaveData(t *testing.T) {
+	files := fstest.MapFS{
+		"app.js":        &fstest.MapFile{Data: []byte("full js")},
+		"app.min.js":    &fstest.MapFile{Data: []byte("min js")},
+		"app.min.js.br": &fstest.MapFile{Data: []byte("compressed min js")},
+		"style.css":     &fstest.MapFile{Data: []byte("full css")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.SaveDataSuffix = ".min"
+
+	t.Run("Save-Data prefers the reduced variant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/app.js", nil)
+		req.Header.Set("Save-Data", "on")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeJS), w.Header().Get("Content-Type"))
+		assert.Equal(t, "Save-Data", w.Header().Get("Vary"))
+		assert.Equal(t, "min js", w.Body.String())
+	})
+
+	t.Run("Reduced variant is compressed when available", func(t *testing.T) {
+		server.BrotliSuffix = ".br"
+		defer func() { server.BrotliSuffix = "" }()
+		req := httptest.NewRequest("GET", "/assets/app.js", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		req.Header.Set("Save-Data", "on")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "compressed min js", w.Body.String())
+	})
+
+	t.Run("Without Save-Data the full file is served", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/app.js", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, "full js", w.Body.String())
+		assert.Equal(t, "Save-Data", w.Header().Get("Vary"))
+	})
+
+	t.Run("Missing reduced variant falls back", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/style.css", nil)
+		req.Header.Set("Save-Data", "on")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "full css", w.Body.String())
+	})
+
+	t.Run("Disabled suffix ignores Save-Data", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		req := httptest.NewRequest("GET", "/assets/app.js", nil)
+		req.Header.Set("Save-Data", "on")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, "full js", w.Body.String())
+		assert.Equal(t, "", w.Header().Get("Vary"))
+	})
+}
+
+// brotliEncode compresses data for use as a test variant
+func brotliEncode(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	writer := brotli.NewWriter(&buf)
+	_, err := writer.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeDirectVariantRequests(t *testing.T) {
+	encoded := brotliEncode(t, "console.log('decoded');")
+	files := fstest.MapFS{
+		"app.js.br":    &fstest.MapFile{Data: encoded},
+		"broken.js.br": &fstest.MapFile{Data: []byte("not brotli")},
+		"style.css":    &fstest.MapFile{Data: []byte("body{}")},
+		"style.css.br": &fstest.MapFile{Data: brotliEncode(t, "body{}")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.BrotliSuffix = ".br"
+	server.DecodeDirectVariantRequests = true
+
+	t.Run("Browser receives decoded content", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/app.js.br", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, utf8Type(mimeTypeJS), w.Header().Get("Content-Type"))
+		assert.Equal(t, "console.log('decoded');", w.Body.String())
+	})
+
+	t.Run("Client accepting br receives raw bytes", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/app.js.br", nil)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, encoded, w.Body.Bytes())
+	})
+
+	t.Run("Negotiated variants are unaffected", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/style.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Corrupt variant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/broken.js.br", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		req := httptest.NewRequest("GET", "/assets/app.js.br", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, encoded, w.Body.Bytes())
+	})
+}
+
+func TestCompressibleMimeType(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		expected bool
+	}{
+		{mimeTypeCSS, true},
+		{mimeTypeJS, true},
+		{"text/html; charset=utf-8", true},
+		{mimeTypeJSON, true},
+		{"application/ld+json", true},
+		{"image/svg+xml", true},
+		{mimeTypePNG, false},
+		{mimeTypeJPG, false},
+		{mimeTypeWOFF2, false},
+		{mimeTypeUnknown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mimeType, func(t *testing.T) {
+			assert.Equal(t, tt.expected, compressibleMimeType(tt.mimeType))
+		})
+	}
+}
+
+func TestOnTheFlyGzip(t *testing.T) {
+	largeCSS := strings.Repeat("body { color: blue; }\n", 100)
+	files := fstest.MapFS{
+		"large.css":      &fstest.MapFile{Data: []byte(largeCSS)},
+		"large.png":      &fstest.MapFile{Data: []byte(strings.Repeat("png", 1000))},
+		"small.css":      &fstest.MapFile{Data: []byte("a{}")},
+		"variant.css":    &fstest.MapFile{Data: []byte(largeCSS)},
+		"variant.css.br": &fstest.MapFile{Data: []byte("brotli-bytes")},
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.EnableGzip = true
+		return server
+	}
+
+	t.Run("Compressible content round-trips", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/large.css", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, gzipEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+		assert.Less(t, w.Body.Len(), len(largeCSS))
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		decoded, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, largeCSS, string(decoded))
+	})
+
+	t.Run("Client without gzip", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/large.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeCSS, w.Body.String())
+	})
+
+	t.Run("Already compressed types are skipped", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/large.png", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Files below GzipMinSize are skipped", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/small.css", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "a{}", w.Body.String())
+
+		server.GzipMinSize = 0
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		assert.Equal(t, gzipEncoding, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Brotli variant wins", func(t *testing.T) {
+		server := newServer(t)
+		server.BrotliSuffix = ".br"
+		req := httptest.NewRequest("GET", "/assets/variant.css", nil)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "brotli-bytes", w.Body.String())
+	})
+
+	t.Run("Gzip satisfies refused identity", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/large.css", nil)
+		req.Header.Set("Accept-Encoding", "gzip, identity;q=0")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, gzipEncoding, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Denied User-Agents are served uncompressed", func(t *testing.T) {
+		server := newServer(t)
+		server.CompressionUADenyList = []*regexp.Regexp{regexp.MustCompile(`LegacyBot`)}
+		req := httptest.NewRequest("GET", "/assets/large.css", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		req.Header.Set("User-Agent", "LegacyBot/1.0")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+}
+
+func TestVaryAcceptEncoding(t *testing.T) {
+	tests := []struct {
+		name           string
+		brotliSuffix   string
+		zstdSuffix     string
+		gzip           bool
+		acceptEncoding string
+		expectedVary   bool
+	}{
+		{"Brotli served", ".br", "", false, "br", true},
+		{"Brotli configured, identity served", ".br", "", false, "", true},
+		{"Zstd configured", "", ".zst", false, "zstd", true},
+		{"Gzip enabled", "", "", true, "gzip", true},
+		{"Compression disabled", "", "", false, "br, gzip", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", testFiles)
+			require.Nil(t, err)
+			server.BrotliSuffix = tt.brotliSuffix
+			server.ZstdSuffix = tt.zstdSuffix
+			server.EnableGzip = tt.gzip
+			req := httptest.NewRequest("GET", "/assets/test.css", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			if tt.expectedVary {
+				assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+			} else {
+				assert.Empty(t, w.Header().Values("Vary"))
+			}
+		})
+	}
+
+	t.Run("Sent with 304 responses", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("If-None-Match", DefaultETagFunc(testFiles["test.css"].Data))
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, []string{"Accept-Encoding"}, w.Header().Values("Vary"))
+	})
+}
+
+func TestIdentityOnly(t *testing.T) {
+	assert.True(t, identityOnly("identity"))
+	assert.True(t, identityOnly("identity, br;q=0"))
+	assert.False(t, identityOnly(""))
+	assert.False(t, identityOnly("gzip, identity"))
+	assert.False(t, identityOnly("identity, *"))
+	assert.False(t, identityOnly("identity;q=0"))
+}
+
+func TestDoubleEncodingDefenses(t *testing.T) {
+	const js = "console.log('decoded');"
+	largeCSS := strings.Repeat("body { color: blue; }\n", 200)
+	files := fstest.MapFS{
+		"app.js":      &fstest.MapFile{Data: []byte(js)},
+		"app.js.br":   &fstest.MapFile{Data: brotliEncode(t, js)},
+		"only.js.br":  &fstest.MapFile{Data: brotliEncode(t, js)},
+		"only.js.zst": &fstest.MapFile{Data: []byte("zstd bytes")},
+		"site.css":    &fstest.MapFile{Data: []byte(largeCSS)},
+		"site.css.br": &fstest.MapFile{Data: brotliEncode(t, largeCSS)},
+		"plain.txt":   &fstest.MapFile{Data: []byte("plain")},
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		return server
+	}
+	get := func(server *AssetServer, urlPath, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", urlPath, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, acceptEncoding := range []string{"identity", "gzip, br;q=0"} {
+		t.Run("Direct variant decoded for "+acceptEncoding, func(t *testing.T) {
+			w := get(newServer(t), "/assets/app.js.br", acceptEncoding)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Values("Content-Encoding"))
+			assert.Equal(t, js, w.Body.String())
+		})
+	}
+
+	t.Run("Direct variant kept for other clients", func(t *testing.T) {
+		w := get(newServer(t), "/assets/app.js.br", "")
+
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Only copy decoded for identity", func(t *testing.T) {
+		w := get(newServer(t), "/assets/only.js", "identity")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Values("Content-Encoding"))
+		assert.Equal(t, js, w.Body.String())
+	})
+
+	t.Run("Undecodable variant not acceptable", func(t *testing.T) {
+		server := newServer(t)
+		server.BrotliSuffix = ""
+		server.ZstdSuffix = ".zst"
+		w := get(server, "/assets/only.js.zst", "identity")
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("Content-Encoding is never added twice", func(t *testing.T) {
+		server := newServer(t)
+		server.HeaderFunc = func(w http.ResponseWriter, data []byte) {
+			w.Header().Add("Content-Encoding", "gzip")
+		}
+
+		brotli := get(server, "/assets/app.js", "br")
+		assert.Equal(t, []string{"br"}, brotli.Header().Values("Content-Encoding"))
+
+		plain := get(server, "/assets/plain.txt", "br")
+		assert.Empty(t, plain.Header().Values("Content-Encoding"))
+		assert.Equal(t, "plain", plain.Body.String())
+	})
+
+	t.Run("Brotli responses are not gzipped again", func(t *testing.T) {
+		server := newServer(t)
+		server.EnableGzip = true
+		w := get(server, "/assets/site.css", "gzip, br")
+
+		assert.Equal(t, []string{"br"}, w.Header().Values("Content-Encoding"))
+		assert.Equal(t, files["site.css.br"].Data, w.Body.Bytes())
+	})
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/maypok86/otter/v2"
+)
+
+// StaticaETagFunc computes the entity tag, including its surrounding quotes, for the
+// bytes of a response
+type StaticaETagFunc func(data []byte) string
+
+// DefaultETagFunc returns a strong ETag containing the hex encoded SHA-256 of data
+func DefaultETagFunc(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag. If-None-Match uses
+// weak comparison, so a W/ prefix on either side is ignored.
+func etagMatches(header, etag string) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModifiedSince reports whether an If-Modified-Since header value is at or after
+// modTime, at the one second precision of HTTP dates. Missing or malformed headers and
+// unknown modification times never match.
+func notModifiedSince(header string, modTime time.Time) bool {
+	if header == "" || modTime.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(since)
+}
+
+// etagKey scopes an entity tag, without any W/ prefix, to the requested path it was sent for
+func etagKey(requestedPath, etag string) string {
+	return requestedPath + namespaceSeparator + strings.TrimPrefix(etag, "W/")
+}
+
+// maxRememberedETags bounds the tags remembered for CacheETags
+const maxRememberedETags = 10_000
+
+// notModifiedHeaders are the representation headers a 304 repeats from the 200 it
+// revalidates. Anything else, such as a Set-Cookie from middleware, belongs to the
+// request it was sent with.
+var notModifiedHeaders = []string{"ETag", "Cache-Control", "Vary", "Content-Location", "Expires", "Last-Modified"}
+
+// rememberedETags returns the cache of remembered tags, creating it on first use
+func (server *AssetServer) rememberedETags() *otter.Cache[string, http.Header] {
+	server.etagsOnce.Do(func() {
+		server.etags = otter.Must(&otter.Options[string, http.Header]{MaximumSize: maxRememberedETags})
+	})
+	return server.etags
+}
+
+// rememberETag records the representation headers sent with etag so cachedNotModified
+// can replay them
+func (server *AssetServer) rememberETag(requestedPath, etag string, header http.Header) {
+	remembered := http.Header{}
+	for _, name := range notModifiedHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			remembered[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+	}
+	server.rememberedETags().Set(etagKey(requestedPath, etag), remembered)
+}
+
+// cachedNotModified answers r with 304 and the remembered headers when its If-None-Match
+// names a tag previously sent for requestedPath. "*" needs the asset to exist, so it is
+// left to the full read.
+func (server *AssetServer) cachedNotModified(w http.ResponseWriter, r *http.Request, requestedPath string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" || candidate == "*" {
+			continue
+		}
+		if remembered, ok := server.rememberedETags().GetIfPresent(etagKey(requestedPath, candidate)); ok {
+			for name, values := range remembered {
+				w.Header()[name] = slices.Clone(values)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// Invalidate drops the ETags remembered and Integrity memoized for requestedPath, a path
+// relative to the route, and removes the file and its precompressed variants from the
+// filesystem's cache when it has one, such as CachingFS. Safe for concurrent use.
+func (server *AssetServer) Invalidate(requestedPath string) {
+	server.integrity.Delete(requestedPath)
+	prefix := requestedPath + namespaceSeparator
+	etags := server.rememberedETags()
+	var stale []string
+	for key := range etags.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			stale = append(stale, key)
+		}
+	}
+	for _, key := range stale {
+		etags.Invalidate(key)
+	}
+	if cache, ok := server.files.(interface{ Invalidate(filePath string) }); ok {
+		filePath := server.fsPath(requestedPath)
+		cache.Invalidate(filePath)
+		for _, encoding := range server.variantEncodings() {
+			cache.Invalidate(filePath + server.variantSuffix(encoding))
+		}
+	}
+}
+
+// InvalidateAll drops every remembered ETag and Integrity value and empties the
+// filesystem's cache when it has one. Safe for concurrent use.
+func (server *AssetServer) InvalidateAll() {
+	server.rememberedETags().InvalidateAll()
+	server.integrity.Clear()
+	if cache, ok := server.files.(interface{ InvalidateAll() }); ok {
+		cache.InvalidateAll()
+	}
+}
@@ -0,0 +1,537 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultETagFunc(t *testing.T) {
+	etag := DefaultETagFunc([]byte("body { color: blue; }"))
+	assert.Len(t, etag, 66)
+	assert.Equal(t, byte('"'), etag[0])
+	assert.Equal(t, byte('"'), etag[len(etag)-1])
+	assert.Equal(t, etag, DefaultETagFunc([]byte("body { color: blue; }")))
+	assert.NotEqual(t, etag, DefaultETagFunc([]byte("body { color: red; }")))
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		etag     string
+		expected bool
+	}{
+		{"Empty header", "", `"abc"`, false},
+		{"Exact match", `"abc"`, `"abc"`, true},
+		{"Mismatch", `"abd"`, `"abc"`, false},
+		{"Match in list", `"one", "abc", "two"`, `"abc"`, true},
+		{"Weak header tag", `W/"abc"`, `"abc"`, true},
+		{"Weak server tag", `"abc"`, `W/"abc"`, true},
+		{"Wildcard", "*", `"abc"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, etagMatches(tt.header, tt.etag))
+		})
+	}
+}
+
+func TestETags(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+	server.HeaderFunc = DefaultHeaderFunc
+
+	t.Run("ETag is sent", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, DefaultETagFunc([]byte("body { color: blue; }")), w.Header().Get("ETag"))
+	})
+
+	t.Run("Matching If-None-Match", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("If-None-Match", DefaultETagFunc([]byte("body { color: blue; }")))
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.NotEmpty(t, w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Mismatched If-None-Match", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("If-None-Match", `"stale"`)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, DefaultETagFunc([]byte("body { color: blue; }")), w.Header().Get("ETag"))
+		assert.Equal(t, "body { color: blue; }", w.Body.String())
+	})
+
+	t.Run("Brotli variant has its own ETag", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, DefaultETagFunc([]byte("compressed-css-data")), w.Header().Get("ETag"))
+	})
+
+	t.Run("Custom ETagFunc", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.ETagFunc = func(data []byte) string { return `"fixed"` }
+		req := httptest.NewRequest("GET", "/assets/test.js", nil)
+		req.Header.Set("If-None-Match", `"fixed"`)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.ETagFunc = nil
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("If-None-Match", "*")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+	})
+}
+
+func TestNotModifiedSince(t *testing.T) {
+	modTime := time.Date(2025, 3, 1, 12, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name     string
+		header   string
+		modTime  time.Time
+		expected bool
+	}{
+		{"Same second", "Sat, 01 Mar 2025 12:00:00 GMT", modTime, true},
+		{"Later", "Sat, 01 Mar 2025 13:00:00 GMT", modTime, true},
+		{"Earlier", "Sat, 01 Mar 2025 11:59:59 GMT", modTime, false},
+		{"Missing header", "", modTime, false},
+		{"Malformed header", "yesterday", modTime, false},
+		{"Unknown modification time", "Sat, 01 Mar 2025 12:00:00 GMT", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, notModifiedSince(tt.header, tt.modTime))
+		})
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	modTime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	files := fstest.MapFS{
+		"dated.css":   &fstest.MapFile{Data: []byte("body{}"), ModTime: modTime},
+		"undated.css": &fstest.MapFile{Data: []byte("p{}")},
+	}
+	etag := DefaultETagFunc([]byte("body{}"))
+	after := modTime.Add(time.Hour).Format(http.TimeFormat)
+	before := modTime.Add(-time.Hour).Format(http.TimeFormat)
+	get := func(t *testing.T, server *AssetServer, p string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", p, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		return server
+	}
+
+	t.Run("Header from the file's modification time", func(t *testing.T) {
+		w := get(t, newServer(t), "/assets/dated.css", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Sat, 01 Mar 2025 12:00:00 GMT", w.Header().Get("Last-Modified"))
+	})
+
+	t.Run("No header without a modification time", func(t *testing.T) {
+		w := get(t, newServer(t), "/assets/undated.css", map[string]string{"If-Modified-Since": after})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		server := newServer(t)
+		server.LastModified = false
+		w := get(t, server, "/assets/dated.css", map[string]string{"If-Modified-Since": after})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+	})
+
+	conditionals := []struct {
+		name        string
+		ifNoneMatch string
+		ifModified  string
+		expected    int
+	}{
+		{"Not modified since", "", after, http.StatusNotModified},
+		{"Modified since", "", before, http.StatusOK},
+		{"Matching ETag wins over a stale date", etag, before, http.StatusNotModified},
+		{"Mismatched ETag wins over a fresh date", `"other"`, after, http.StatusOK},
+	}
+	for _, tt := range conditionals {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{"If-Modified-Since": tt.ifModified}
+			if tt.ifNoneMatch != "" {
+				headers["If-None-Match"] = tt.ifNoneMatch
+			}
+			w := get(t, newServer(t), "/assets/dated.css", headers)
+
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusNotModified {
+				assert.Empty(t, w.Body.Bytes())
+				assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
+
+func TestConditionalPrecedence(t *testing.T) {
+	modTime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	files := fstest.MapFS{"app.css": &fstest.MapFile{Data: []byte("body{}"), ModTime: modTime}}
+	etag := DefaultETagFunc([]byte("body{}"))
+	fresh := modTime.Add(time.Hour).Format(http.TimeFormat)
+	stale := modTime.Add(-time.Hour).Format(http.TimeFormat)
+
+	modes := []struct {
+		name      string
+		configure func(server *AssetServer)
+	}{
+		{"Buffered", func(server *AssetServer) {}},
+		{"CacheETags", func(server *AssetServer) { server.CacheETags = true }},
+	}
+	// If-None-Match takes precedence, so If-Modified-Since never changes the outcome
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		ifModified  string
+		expected    int
+	}{
+		{"Match with fresh date", etag, fresh, http.StatusNotModified},
+		{"Match with stale date", etag, stale, http.StatusNotModified},
+		{"Mismatch with fresh date", `"other"`, fresh, http.StatusOK},
+		{"Mismatch with stale date", `"other"`, stale, http.StatusOK},
+	}
+
+	for _, mode := range modes {
+		for _, tt := range tests {
+			t.Run(mode.name+"/"+tt.name, func(t *testing.T) {
+				server, err := NewAssetServer("/assets/", files)
+				require.Nil(t, err)
+				mode.configure(server)
+				// the first response primes CacheETags
+				server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/assets/app.css", nil))
+
+				req := httptest.NewRequest("GET", "/assets/app.css", nil)
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+				req.Header.Set("If-Modified-Since", tt.ifModified)
+				w := httptest.NewRecorder()
+				server.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.expected, w.Code)
+				if tt.expected == http.StatusOK {
+					assert.Equal(t, "body{}", w.Body.String())
+				} else {
+					assert.Empty(t, w.Body.Bytes())
+				}
+			})
+		}
+	}
+}
+
+func TestCacheETags(t *testing.T) {
+	css := []byte("body { color: blue; }")
+	etag := DefaultETagFunc(css)
+	newServer := func(t *testing.T) (*AssetServer, *countingFS) {
+		files := &countingFS{files: fstest.MapFS{
+			"app.css":    &fstest.MapFile{Data: css},
+			"app.css.br": &fstest.MapFile{Data: []byte("compressed")},
+		}}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		server.CacheETags = true
+		return server, files
+	}
+	get := func(server *AssetServer, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/assets/app.css", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Conditional GET skips the filesystem", func(t *testing.T) {
+		server, files := newServer(t)
+		first := get(server, "")
+		require.Equal(t, http.StatusOK, first.Code)
+		reads, opens := files.reads.Load(), files.opens.Load()
+
+		w := get(server, etag)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, reads, files.reads.Load())
+		assert.Equal(t, opens, files.opens.Load())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		assert.Equal(t, first.Header().Values("Vary"), w.Header().Values("Vary"))
+		assert.Equal(t, first.Header().Get("Last-Modified"), w.Header().Get("Last-Modified"))
+		assert.Empty(t, w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Weak comparison", func(t *testing.T) {
+		server, files := newServer(t)
+		get(server, "")
+		reads := files.reads.Load()
+
+		w := get(server, `"other", W/`+etag)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, reads, files.reads.Load())
+	})
+
+	t.Run("Tags are remembered per encoding", func(t *testing.T) {
+		server, files := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/app.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		first := httptest.NewRecorder()
+		server.ServeHTTP(first, req)
+		brTag := first.Header().Get("ETag")
+		reads := files.reads.Load()
+
+		req.Header.Set("If-None-Match", brTag)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, brTag, w.Header().Get("ETag"))
+		assert.Equal(t, reads, files.reads.Load())
+
+		// Only the Brotli tag has been sent, so the identity tag still needs a read
+		assert.Equal(t, http.StatusNotModified, get(server, etag).Code)
+		assert.Greater(t, files.reads.Load(), reads)
+	})
+
+	t.Run("Per-request headers are not replayed", func(t *testing.T) {
+		server, _ := newServer(t)
+		sessions := 0
+		server.Middleware = []func(http.Handler) http.Handler{func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sessions++
+				w.Header().Set("Set-Cookie", fmt.Sprintf("session=%d", sessions))
+				next.ServeHTTP(w, r)
+			})
+		}}
+		server.HeaderFunc = func(w http.ResponseWriter, data []byte) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("X-Served-By", "origin")
+		}
+		require.Equal(t, "session=1", get(server, "").Header().Get("Set-Cookie"))
+
+		w := get(server, etag)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, "session=2", w.Header().Get("Set-Cookie"))
+		assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+		assert.Empty(t, w.Header().Get("X-Served-By"))
+	})
+
+	t.Run("Remembered tags are bounded", func(t *testing.T) {
+		server, _ := newServer(t)
+		for i := range maxRememberedETags + 100 {
+			server.rememberETag("app.css", fmt.Sprintf(`"%d"`, i), http.Header{})
+		}
+		server.rememberedETags().CleanUp()
+		assert.LessOrEqual(t, server.rememberedETags().EstimatedSize(), maxRememberedETags)
+	})
+
+	t.Run("Unknown tags read the asset", func(t *testing.T) {
+		server, files := newServer(t)
+		get(server, "")
+		reads := files.reads.Load()
+
+		assert.Equal(t, http.StatusOK, get(server, `"stale"`).Code)
+		assert.Equal(t, http.StatusNotModified, get(server, "*").Code)
+		assert.Greater(t, files.reads.Load(), reads)
+	})
+
+	t.Run("Disabled reads every time", func(t *testing.T) {
+		server, files := newServer(t)
+		server.CacheETags = false
+		get(server, "")
+		reads := files.reads.Load()
+
+		assert.Equal(t, http.StatusNotModified, get(server, etag).Code)
+		assert.Greater(t, files.reads.Load(), reads)
+	})
+
+	t.Run("Invalidate drops stale tags", func(t *testing.T) {
+		server, files := newServer(t)
+		get(server, "")
+		files.files["app.css"] = &fstest.MapFile{Data: []byte("body { color: red; }")}
+		server.Invalidate("app.css")
+
+		w := get(server, etag)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "body { color: red; }", w.Body.String())
+	})
+
+	t.Run("Invalidate clears CachingFS entries", func(t *testing.T) {
+		files := fstest.MapFS{
+			"app.css":    &fstest.MapFile{Data: css},
+			"app.css.br": &fstest.MapFile{Data: []byte("compressed")},
+		}
+		cfs, err := NewDefaultCachingFS(files)
+		require.NoError(t, err)
+		server, err := NewAssetServer("/assets/", cfs)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		server.CacheETags = true
+		get(server, "")
+		require.NotEmpty(t, cfs.Keys())
+
+		server.Invalidate("app.css")
+		assert.Empty(t, cfs.Keys())
+
+		get(server, "")
+		files["app.css"] = &fstest.MapFile{Data: []byte("body { color: red; }")}
+		server.InvalidateAll()
+		assert.Empty(t, cfs.Keys())
+		assert.Equal(t, http.StatusOK, get(server, etag).Code)
+	})
+}
+
+func TestBypassCacheParam(t *testing.T) {
+	newServer := func(t *testing.T) (*AssetServer, *countingFS) {
+		counting := &countingFS{files: fstest.MapFS{
+			"app.css":    &fstest.MapFile{Data: []byte("body { color: blue; }")},
+			"index.html": &fstest.MapFile{Data: []byte("<h1>v1</h1>")},
+		}}
+		cfs, err := NewDefaultCachingFS(counting)
+		require.NoError(t, err)
+		server, err := NewAssetServer("/assets/", cfs)
+		require.Nil(t, err)
+		server.IndexFile = "index.html"
+		server.CacheETags = true
+		server.BypassCacheParam = "nocache"
+		return server, counting
+	}
+	get := func(server *AssetServer, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	t.Run("Param forces a fresh read", func(t *testing.T) {
+		server, counting := newServer(t)
+		get(server, "/assets/app.css")
+		get(server, "/assets/app.css")
+		require.Equal(t, int64(1), counting.reads.Load())
+
+		counting.files["app.css"] = &fstest.MapFile{Data: []byte("body { color: red; }")}
+		assert.Equal(t, "body { color: blue; }", get(server, "/assets/app.css").Body.String())
+
+		w := get(server, "/assets/app.css?nocache")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "body { color: red; }", w.Body.String())
+		assert.Equal(t, int64(2), counting.reads.Load())
+
+		// the fresh copy is cached for requests without the param
+		assert.Equal(t, "body { color: red; }", get(server, "/assets/app.css").Body.String())
+		assert.Equal(t, int64(2), counting.reads.Load())
+	})
+
+	t.Run("Param with a value", func(t *testing.T) {
+		server, counting := newServer(t)
+		get(server, "/assets/app.css")
+		get(server, "/assets/app.css?nocache=1&v=2")
+		assert.Equal(t, int64(2), counting.reads.Load())
+	})
+
+	t.Run("Index files", func(t *testing.T) {
+		server, counting := newServer(t)
+		get(server, "/assets/")
+		counting.files["index.html"] = &fstest.MapFile{Data: []byte("<h1>v2</h1>")}
+
+		assert.Equal(t, "<h1>v2</h1>", get(server, "/assets/?nocache").Body.String())
+	})
+
+	t.Run("Remembered ETags are dropped", func(t *testing.T) {
+		server, counting := newServer(t)
+		etag := get(server, "/assets/app.css").Header().Get("ETag")
+		counting.files["app.css"] = &fstest.MapFile{Data: []byte("body { color: red; }")}
+
+		req := httptest.NewRequest("GET", "/assets/app.css?nocache", nil)
+		req.Header.Set("If-None-Match", etag)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "body { color: red; }", w.Body.String())
+	})
+
+	t.Run("Other params and an empty setting use the cache", func(t *testing.T) {
+		server, counting := newServer(t)
+		get(server, "/assets/app.css")
+		get(server, "/assets/app.css?v=nocache")
+		assert.Equal(t, int64(1), counting.reads.Load())
+
+		server.BypassCacheParam = ""
+		get(server, "/assets/app.css?nocache")
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+}
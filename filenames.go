@@ -0,0 +1,99 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// reservedNames are device names Windows treats specially in any directory, with or
+// without an extension
+var reservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true,
+	"com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true,
+	"lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// portablePath reports whether every element of a slash separated path is a filename
+// which behaves the same on Windows and Unix filesystems: not a reserved device name
+// such as "con" or "nul.txt", and not ending in a dot or space.
+func portablePath(requestedPath string) bool {
+	for _, element := range strings.Split(requestedPath, "/") {
+		if element == "" || element == "." || element == ".." {
+			continue
+		}
+		if strings.HasSuffix(element, ".") || strings.HasSuffix(element, " ") {
+			return false
+		}
+		base, _, _ := strings.Cut(element, ".")
+		if reservedNames[strings.ToLower(strings.TrimRight(base, " "))] {
+			return false
+		}
+	}
+	return true
+}
+
+// hiddenPath reports whether any element of the cleaned path is a dotfile or dot
+// directory, such as ".env" or ".git/config"
+func hiddenPath(requestedPath string) bool {
+	for _, element := range strings.Split(path.Clean(requestedPath), "/") {
+		if element != "." && strings.HasPrefix(element, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// malformedPath reports whether a request URL's escaped path fails to decode, e.g.
+// "%zz", or its decoded path holds control characters such as NUL, which no asset name
+// should contain
+func malformedPath(u *url.URL) bool {
+	if u.RawPath != "" {
+		if _, err := url.PathUnescape(u.RawPath); err != nil {
+			return true
+		}
+	}
+	return strings.ContainsFunc(u.Path, unicode.IsControl)
+}
+
+// symlinked reports whether filePath, or any directory along it below root, is a symbolic
+// link in files. Links in root itself, such as an FSPrefix pointing at the current
+// release, are the operator's. Filesystems without fs.ReadLinkFS can't report links, so
+// nothing is found in them, and paths which can't be examined are left for the read to fail.
+func symlinked(files fs.FS, root, filePath string) bool {
+	linkFS, ok := files.(fs.ReadLinkFS)
+	if !ok || !strings.HasPrefix(filePath, root) {
+		return false
+	}
+	for i := len(root); i <= len(filePath); i++ {
+		if i < len(filePath) && filePath[i] != '/' {
+			continue
+		}
+		info, err := linkFS.Lstat(filePath[:i])
+		if err != nil {
+			return false
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,302 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortablePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"app.js", true},
+		{"css/site.css", true},
+		{"console.log", true},
+		{"auxiliary/file.txt", true},
+		{"com10.txt", true},
+		{"con", false},
+		{"CON", false},
+		{"nul.txt", false},
+		{"docs/Aux.tar.gz", false},
+		{"lpt1/readme.md", false},
+		{"com9", false},
+		{"con .txt", false},
+		{"file.", false},
+		{"file ", false},
+		{"dir./file.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, portablePath(tt.path))
+		})
+	}
+}
+
+func TestStrictFilenames(t *testing.T) {
+	files := fstest.MapFS{
+		"con.txt":   &fstest.MapFile{Data: []byte("device")},
+		"notes.":    &fstest.MapFile{Data: []byte("trailing dot")},
+		"notes.txt": &fstest.MapFile{Data: []byte("regular")},
+	}
+
+	tests := []struct {
+		name           string
+		strict         bool
+		path           string
+		expectedStatus int
+	}{
+		{"Reserved name served when lenient", false, "/assets/con.txt", http.StatusOK},
+		{"Trailing dot served when lenient", false, "/assets/notes.", http.StatusOK},
+		{"Reserved name rejected", true, "/assets/con.txt", http.StatusNotFound},
+		{"Trailing dot rejected", true, "/assets/notes.", http.StatusNotFound},
+		{"Regular name served", true, "/assets/notes.txt", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", files)
+			require.Nil(t, err)
+			server.StrictFilenames = tt.strict
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestHiddenPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"app.js", false},
+		{"css/site.css", false},
+		{"", false},
+		{"sub/", false},
+		{"./app.js", false},
+		{".env", true},
+		{"sub/.secret", true},
+		{".git/config", true},
+		{"a/.well-known/b", true},
+		{"app.js/.", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hiddenPath(tt.path))
+		})
+	}
+}
+
+func TestHideDotfiles(t *testing.T) {
+	files := fstest.MapFS{
+		".env":           &fstest.MapFile{Data: []byte("SECRET=1")},
+		".env.br":        &fstest.MapFile{Data: []byte("brotli")},
+		"sub/.secret":    &fstest.MapFile{Data: []byte("secret")},
+		"sub/app.txt":    &fstest.MapFile{Data: []byte("app")},
+		"sub/app.txt.br": &fstest.MapFile{Data: []byte("brotli app")},
+	}
+
+	tests := []struct {
+		name           string
+		hide           bool
+		path           string
+		acceptEncoding string
+		expectedStatus int
+	}{
+		{"Dotfile served when disabled", false, "/assets/.env", "", http.StatusOK},
+		{"Nested dotfile served when disabled", false, "/assets/sub/.secret", "", http.StatusOK},
+		{"Dotfile rejected", true, "/assets/.env", "", http.StatusNotFound},
+		{"Nested dotfile rejected", true, "/assets/sub/.secret", "", http.StatusNotFound},
+		{"Brotli variant of dotfile rejected", true, "/assets/.env", "br", http.StatusNotFound},
+		{"Variant requested directly rejected", true, "/assets/.env.br", "", http.StatusNotFound},
+		{"Regular file served", true, "/assets/sub/app.txt", "br", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", files)
+			require.Nil(t, err)
+			server.BrotliSuffix = ".br"
+			server.HideDotfiles = tt.hide
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestMalformedPath(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		url       url.URL
+		malformed bool
+	}{
+		{"Plain path", url.URL{Path: "/assets/app.js"}, false},
+		{"Escaped path", url.URL{Path: "/assets/a b.js", RawPath: "/assets/a%20b.js"}, false},
+		{"Literal percent", url.URL{Path: "/assets/100%zz.txt", RawPath: "/assets/100%25zz.txt"}, false},
+		{"Bad escape", url.URL{Path: "/assets/%zz", RawPath: "/assets/%zz"}, true},
+		{"Truncated escape", url.URL{Path: "/assets/app%2", RawPath: "/assets/app%2"}, true},
+		{"Null byte", url.URL{Path: "/assets/app.js\x00.txt"}, true},
+		{"Newline", url.URL{Path: "/assets/app\n.js"}, true},
+		{"Delete", url.URL{Path: "/assets/app\x7f.js"}, true},
+		{"Unicode", url.URL{Path: "/assets/caf\u00e9.js"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.malformed, malformedPath(&tc.url))
+		})
+	}
+}
+
+func TestFollowSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(outside, "private"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "private", "key.txt"), []byte("key"), 0o600))
+
+	root := t.TempDir()
+	release := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(release, "app.css"), []byte("body{}"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "index.html"), []byte("<h1>home</h1>"), 0o644))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "leak.txt")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "private"), filepath.Join(root, "linked")))
+	require.NoError(t, os.Symlink(release, filepath.Join(root, "current")))
+
+	get := func(server *AssetServer, p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+		return w
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", os.DirFS(root))
+		require.NoError(t, err)
+		return server
+	}
+
+	t.Run("Symlinked file is refused", func(t *testing.T) {
+		server := newServer(t)
+		var got error
+		server.ErrorLogFunc = func(r *http.Request, err error) {
+			got = err
+		}
+		w := get(server, "/assets/leak.txt")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "secret")
+		assert.ErrorIs(t, got, fs.ErrPermission)
+	})
+
+	t.Run("Symlinked directory is refused", func(t *testing.T) {
+		w := get(newServer(t), "/assets/linked/key.txt")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Regular files are served", func(t *testing.T) {
+		w := get(newServer(t), "/assets/index.html")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<h1>home</h1>", w.Body.String())
+	})
+
+	t.Run("Streamed files are refused", func(t *testing.T) {
+		server := newServer(t)
+		server.StreamThreshold = 1
+		w := get(server, "/assets/leak.txt")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Body.String(), "secret")
+	})
+
+	t.Run("Through CachingFS", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(os.DirFS(root).(fs.ReadFileFS))
+		require.NoError(t, err)
+		server, err := NewAssetServer("/assets/", cfs)
+		require.NoError(t, err)
+
+		// refused links are still refused once their contents are cached
+		for range 2 {
+			w := get(server, "/assets/leak.txt")
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.NotContains(t, w.Body.String(), "secret")
+		}
+		assert.Equal(t, http.StatusOK, get(server, "/assets/index.html").Code)
+	})
+
+	t.Run("FSPrefix may be a link", func(t *testing.T) {
+		server := newServer(t)
+		server.FSPrefix = "current/"
+		w := get(server, "/assets/app.css")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "body{}", w.Body.String())
+	})
+
+	t.Run("FollowSymlinks serves links", func(t *testing.T) {
+		server := newServer(t)
+		server.FollowSymlinks = true
+		assert.Equal(t, "secret", get(server, "/assets/leak.txt").Body.String())
+		assert.Equal(t, "key", get(server, "/assets/linked/key.txt").Body.String())
+	})
+
+	t.Run("Listings skip links", func(t *testing.T) {
+		server := newServer(t)
+		assets, err := server.ListAssets()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"index.html"}, assets)
+
+		_, err = server.PrecacheManifest()
+		assert.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		server.NewManifestHandler(0).ServeHTTP(w, httptest.NewRequest("GET", "/assets/manifest.json", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "leak.txt")
+	})
+
+	t.Run("Listings include linked files with FollowSymlinks", func(t *testing.T) {
+		server := newServer(t)
+		server.FollowSymlinks = true
+		assets, err := server.ListAssets()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"index.html", "leak.txt"}, assets)
+	})
+
+	t.Run("Filesystems without links", func(t *testing.T) {
+		assert.False(t, symlinked(openOnlyFS{os.DirFS(root)}, "", "leak.txt"))
+		assert.False(t, symlinked(fstest.MapFS{"a/b.txt": &fstest.MapFile{}}, "", "a/b.txt"))
+		assert.True(t, symlinked(os.DirFS(root), "", "linked/key.txt"))
+		assert.False(t, symlinked(os.DirFS(root), "current/", "current/app.css"))
+		assert.False(t, symlinked(os.DirFS(root), "", "missing/file.txt"))
+	})
+}
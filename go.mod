@@ -3,6 +3,7 @@ module github.com/poiesic/statica
 go 1.25.1
 
 require (
+	github.com/andybalholm/brotli v1.2.5
 	github.com/maypok86/otter/v2 v2.2.1
 	github.com/stretchr/testify v1.11.1
 )
@@ -0,0 +1,98 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// memFile is a read-only fs.File over contents already in memory. It is seekable, so
+// http.FileServer and http.ServeContent can answer Range requests from it.
+type memFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+// memFileInfo reports the size of the contents held in memory, which may be a cached
+// copy of a file that has since changed size
+type memFileInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (info memFileInfo) Size() int64 {
+	return info.size
+}
+
+// readThroughFS opens regular files by reading them whole with readFile, so a cache
+// behind readFile serves their contents. Directories are opened on files directly.
+type readThroughFS struct {
+	files    fs.FS
+	readFile func(name string) ([]byte, error)
+}
+
+func (rfs readThroughFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := fs.Stat(rfs.files, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return rfs.files.Open(name)
+	}
+	data, err := rfs.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{Reader: bytes.NewReader(data), info: memFileInfo{FileInfo: info, size: int64(len(data))}}, nil
+}
+
+// HTTPFileSystem returns the cached files as an http.FileSystem, e.g. for
+// http.FileServer. File contents come from the cache; directory listings and file
+// metadata come from the underlying filesystem.
+func (cfs *CachingFS) HTTPFileSystem() http.FileSystem {
+	return http.FS(readThroughFS{files: cfs.fs.files, readFile: cfs.ReadFile})
+}
+
+// HTTPFileSystem returns the server's assets, below FSPrefix, as an http.FileSystem for
+// use outside ServeHTTP, such as with http.FileServer. Reads go through CachingFS and
+// CacheNamespace like the server's own; its serving options, e.g. HideDotfiles, don't apply.
+func (server *AssetServer) HTTPFileSystem() (http.FileSystem, error) {
+	var files fs.FS = server.files
+	if server.FSPrefix != "" {
+		sub, err := fs.Sub(server.files, strings.TrimSuffix(server.FSPrefix, "/"))
+		if err != nil {
+			return nil, err
+		}
+		files = sub
+	}
+	return http.FS(readThroughFS{files: files, readFile: func(name string) ([]byte, error) {
+		return server.readFS(context.Background(), server.fsPath(name))
+	}}), nil
+}
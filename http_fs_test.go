@@ -0,0 +1,132 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingFS_HTTPFileSystem(t *testing.T) {
+	newFileServer := func(t *testing.T) (http.Handler, *countingFS) {
+		files := &countingFS{files: fstest.MapFS{
+			"app.css":         &fstest.MapFile{Data: []byte("body { color: blue; }")},
+			"docs/index.html": &fstest.MapFile{Data: []byte("<h1>Docs</h1>")},
+		}}
+		cfs, err := NewDefaultCachingFS(files)
+		require.NoError(t, err)
+		return http.FileServer(cfs.HTTPFileSystem()), files
+	}
+	get := func(handler http.Handler, urlPath, byteRange string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", urlPath, nil)
+		if byteRange != "" {
+			req.Header.Set("Range", byteRange)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Serves cached contents", func(t *testing.T) {
+		handler, files := newFileServer(t)
+		for i := 0; i < 3; i++ {
+			w := get(handler, "/app.css", "")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "body { color: blue; }", w.Body.String())
+			assert.Equal(t, "text/css; charset=utf-8", w.Header().Get("Content-Type"))
+		}
+		assert.Equal(t, int64(1), files.reads.Load())
+	})
+
+	t.Run("Range requests seek", func(t *testing.T) {
+		handler, _ := newFileServer(t)
+		w := get(handler, "/app.css", "bytes=7-11")
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "color", w.Body.String())
+	})
+
+	t.Run("Directory index and listing", func(t *testing.T) {
+		handler, _ := newFileServer(t)
+
+		docs := get(handler, "/docs/", "")
+		assert.Equal(t, http.StatusOK, docs.Code)
+		assert.Equal(t, "<h1>Docs</h1>", docs.Body.String())
+
+		root := get(handler, "/", "")
+		assert.Equal(t, http.StatusOK, root.Code)
+		assert.Contains(t, root.Body.String(), `href="app.css"`)
+		assert.Contains(t, root.Body.String(), `href="docs/"`)
+	})
+
+	t.Run("Missing files", func(t *testing.T) {
+		handler, _ := newFileServer(t)
+
+		assert.Equal(t, http.StatusNotFound, get(handler, "/missing.css", "").Code)
+	})
+
+	t.Run("Files seek and report their size", func(t *testing.T) {
+		cfs, err := NewDefaultCachingFS(fstest.MapFS{
+			"app.css": &fstest.MapFile{Data: []byte("body { color: blue; }")},
+		})
+		require.NoError(t, err)
+		file, err := cfs.HTTPFileSystem().Open("/app.css")
+		require.NoError(t, err)
+		defer file.Close()
+
+		info, err := file.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, int64(21), info.Size())
+		_, err = file.Seek(7, io.SeekStart)
+		require.NoError(t, err)
+		rest, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, "color: blue; }", string(rest))
+	})
+}
+
+func TestAssetServer_HTTPFileSystem(t *testing.T) {
+	files := &countingFS{files: fstest.MapFS{
+		"public/app.js":   &fstest.MapFile{Data: []byte("console.log('app')")},
+		"private/key.pem": &fstest.MapFile{Data: []byte("secret")},
+	}}
+	cfs, err := NewDefaultCachingFS(files)
+	require.NoError(t, err)
+	server, err := NewAssetServer("/assets/", cfs)
+	require.Nil(t, err)
+	server.FSPrefix = "public/"
+	httpFS, err := server.HTTPFileSystem()
+	require.NoError(t, err)
+	handler := http.StripPrefix("/files", http.FileServer(httpFS))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/files/app.js", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "console.log('app')", w.Body.String())
+	}
+	assert.Equal(t, int64(1), files.reads.Load())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/files/../private/key.pem", nil))
+	assert.NotEqual(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+}
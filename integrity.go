@@ -0,0 +1,49 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+)
+
+// Integrity returns the Subresource Integrity value, "sha384-" and the base64 digest,
+// of the asset at filePath, relative to the route, for integrity="..." attributes.
+// The digest covers the content clients see once any Content-Encoding is removed: the
+// uncompressed file after Transforms or, when only a Brotli variant exists, its decoded
+// bytes. Results are memoized until Invalidate or InvalidateAll is called.
+// Safe for concurrent use.
+func (server *AssetServer) Integrity(filePath string) (string, error) {
+	if integrity, ok := server.integrity.Load(filePath); ok {
+		return integrity.(string), nil
+	}
+	data, err := server.readFS(context.Background(), server.fsPath(filePath))
+	if err == nil {
+		data = server.applyTransforms(filePath, server.inferMimeType(filePath), data)
+	} else if errors.Is(err, fs.ErrNotExist) && server.BrotliSuffix != "" {
+		// Brotli-only copies are served decoded, so their digest is of the decoded bytes
+		compressed, variantErr := server.readFS(context.Background(), server.fsPath(filePath)+server.BrotliSuffix)
+		if variantErr == nil {
+			data, err = decodeBrotli(compressed)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	integrity := integrityHash(data)
+	server.integrity.Store(filePath, integrity)
+	return integrity, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sri computes a Subresource Integrity value independently of integrityHash
+func sri(data string) string {
+	sum := sha512.New384()
+	sum.Write([]byte(data))
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum.Sum(nil))
+}
+
+func TestIntegrity(t *testing.T) {
+	const js = "console.log('app');"
+	newServer := func(t *testing.T) (*AssetServer, *countingFS) {
+		files := &countingFS{files: fstest.MapFS{
+			"static/app.js":       &fstest.MapFile{Data: []byte(js)},
+			"static/app.js.br":    &fstest.MapFile{Data: brotliEncode(t, "stale variant")},
+			"static/only.js.br":   &fstest.MapFile{Data: brotliEncode(t, js)},
+			"static/site.css":     &fstest.MapFile{Data: []byte("body {}")},
+			"static/broken.js.br": &fstest.MapFile{Data: []byte("not brotli")},
+		}}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.FSPrefix = "static/"
+		server.BrotliSuffix = ".br"
+		return server, files
+	}
+
+	t.Run("Digest of the file", func(t *testing.T) {
+		server, _ := newServer(t)
+		integrity, err := server.Integrity("app.js")
+		require.NoError(t, err)
+		assert.Equal(t, sri(js), integrity)
+	})
+
+	t.Run("Brotli-only copy is digested decoded", func(t *testing.T) {
+		server, _ := newServer(t)
+		integrity, err := server.Integrity("only.js")
+		require.NoError(t, err)
+		assert.Equal(t, sri(js), integrity)
+	})
+
+	t.Run("Transforms are included", func(t *testing.T) {
+		server, _ := newServer(t)
+		server.Transforms = []StaticaTransformFunc{
+			func(filePath, mimeType string, data []byte) []byte {
+				return bytes.ReplaceAll(data, []byte("{}"), []byte("{ margin: 0 }"))
+			},
+		}
+		integrity, err := server.Integrity("site.css")
+		require.NoError(t, err)
+		assert.Equal(t, sri("body { margin: 0 }"), integrity)
+	})
+
+	t.Run("Digest matches the served bytes", func(t *testing.T) {
+		server, _ := newServer(t)
+		server.Transforms = []StaticaTransformFunc{
+			func(filePath, mimeType string, data []byte) []byte {
+				return append(data, " /* footer */"...)
+			},
+		}
+		for _, name := range []string{"site.css", "only.js"} {
+			req := httptest.NewRequest("GET", "/assets/"+name, nil)
+			req.Header.Set("Accept-Encoding", "identity")
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, name)
+			integrity, err := server.Integrity(name)
+			require.NoError(t, err)
+			assert.Equal(t, sri(w.Body.String()), integrity, name)
+		}
+	})
+
+	t.Run("Memoized until invalidated", func(t *testing.T) {
+		server, files := newServer(t)
+		first, err := server.Integrity("app.js")
+		require.NoError(t, err)
+		files.files["static/app.js"] = &fstest.MapFile{Data: []byte("changed")}
+
+		second, err := server.Integrity("app.js")
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.Equal(t, int64(1), files.reads.Load())
+
+		server.Invalidate("app.js")
+		third, err := server.Integrity("app.js")
+		require.NoError(t, err)
+		assert.Equal(t, sri("changed"), third)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		server, _ := newServer(t)
+		_, err := server.Integrity("missing.js")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		_, err = server.Integrity("broken.js")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// LanguageNegotiation configures Accept-Language based selection of files following the
+// name.lang.ext convention. A request for page.html with "Accept-Language: fr" is served
+// from page.fr.html when it exists.
+type LanguageNegotiation struct {
+	// Languages lists the language tags variants may exist for, e.g. "en", "fr", "pt-BR"
+	Languages []string
+	// Default is tried when no acceptable language has a variant. The unsuffixed file
+	// is served when the default has no variant either.
+	Default string
+}
+
+// candidates returns the languages to try for an Accept-Language header, best first
+func (negotiation *LanguageNegotiation) candidates(acceptLanguage string) []string {
+	type ranked struct {
+		language string
+		quality  float64
+		order    int
+	}
+	var matches []ranked
+	for tag, quality := range parseQualityValues(acceptLanguage) {
+		if quality <= 0 {
+			continue
+		}
+		for i, language := range negotiation.Languages {
+			lower := strings.ToLower(language)
+			if lower == tag || strings.HasPrefix(tag, lower+"-") {
+				matches = append(matches, ranked{language, quality, i})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].quality != matches[j].quality {
+			return matches[i].quality > matches[j].quality
+		}
+		return matches[i].order < matches[j].order
+	})
+	seen := make(map[string]bool)
+	var languages []string
+	for _, match := range matches {
+		if !seen[match.language] {
+			seen[match.language] = true
+			languages = append(languages, match.language)
+		}
+	}
+	if negotiation.Default != "" && !seen[negotiation.Default] {
+		languages = append(languages, negotiation.Default)
+	}
+	return languages
+}
+
+// languagePath inserts language before the extension of filePath
+func languagePath(filePath string, language string) string {
+	ext := path.Ext(filePath)
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(filePath, ext), language, ext)
+}
+
+// readLanguageVariant reads the best language variant of requestedPath for the request,
+// falling back to requestedPath itself. Returns the data, its content coding, and the path
+// which was served.
+func (server *AssetServer) readLanguageVariant(w http.ResponseWriter, r *http.Request, requestedPath string) ([]byte, string, string, error) {
+	w.Header().Add("Vary", "Accept-Language")
+	for _, language := range server.LanguageNegotiation.candidates(r.Header.Get("Accept-Language")) {
+		variantPath := languagePath(requestedPath, language)
+		data, encoding, err := server.readAsset(r, variantPath)
+		if err == nil {
+			w.Header().Set("Content-Language", language)
+			return data, encoding, variantPath, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, "", variantPath, err
+		}
+	}
+	data, encoding, err := server.readAsset(r, requestedPath)
+	return data, encoding, requestedPath, err
+}
+
+// pathLanguage returns the language tag LanguagePattern captures from filePath, if any
+func (server *AssetServer) pathLanguage(filePath string) string {
+	if server.LanguagePattern == nil {
+		return ""
+	}
+	match := server.LanguagePattern.FindStringSubmatch(filePath)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageCandidates(t *testing.T) {
+	negotiation := &LanguageNegotiation{Languages: []string{"en", "fr", "pt-BR"}, Default: "en"}
+	tests := []struct {
+		name     string
+		header   string
+		expected []string
+	}{
+		{"No header uses default", "", []string{"en"}},
+		{"Exact match", "fr", []string{"fr", "en"}},
+		{"Region falls back to language", "fr-CA", []string{"fr", "en"}},
+		{"Case insensitive", "PT-br", []string{"pt-BR", "en"}},
+		{"Ordered by quality", "en;q=0.5, fr;q=0.9", []string{"fr", "en"}},
+		{"Refused language skipped", "fr;q=0, de", []string{"en"}},
+		{"Unavailable languages ignored", "de, ja", []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, negotiation.candidates(tt.header))
+		})
+	}
+}
+
+func TestLanguageNegotiation(t *testing.T) {
+	files := fstest.MapFS{
+		"page.html":         &fstest.MapFile{Data: []byte("unsuffixed")},
+		"page.en.html":      &fstest.MapFile{Data: []byte("hello")},
+		"page.fr.html":      &fstest.MapFile{Data: []byte("bonjour")},
+		"other.html":        &fstest.MapFile{Data: []byte("no variants")},
+		"docs/guide.txt":    &fstest.MapFile{Data: []byte("guide")},
+		"docs/guide.fr.txt": &fstest.MapFile{Data: []byte("guide fr")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.LanguageNegotiation = &LanguageNegotiation{Languages: []string{"en", "fr"}, Default: "en"}
+
+	tests := []struct {
+		name             string
+		path             string
+		acceptLanguage   string
+		expectedBody     string
+		expectedLanguage string
+	}{
+		{"Preferred language variant", "/assets/page.html", "fr-FR,fr;q=0.9", "bonjour", "fr"},
+		{"Default language variant", "/assets/page.html", "de", "hello", "en"},
+		{"No header uses default", "/assets/page.html", "", "hello", "en"},
+		{"No variants serves original", "/assets/other.html", "fr", "no variants", ""},
+		{"Nested paths", "/assets/docs/guide.txt", "fr", "guide fr", "fr"},
+		{"Nested default missing serves original", "/assets/docs/guide.txt", "en", "guide", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, tt.expectedLanguage, w.Header().Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+		})
+	}
+
+	t.Run("Mime type comes from the requested file", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/page.html", nil)
+		req.Header.Set("Accept-Language", "fr")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, utf8Type(mimeTypeHTML), w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/missing.html", nil)
+		req.Header.Set("Accept-Language", "fr")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestLanguagePattern(t *testing.T) {
+	files := fstest.MapFS{
+		"page.en.html":    &fstest.MapFile{Data: []byte("hello")},
+		"page.fr.html":    &fstest.MapFile{Data: []byte("bonjour")},
+		"page.pt-BR.html": &fstest.MapFile{Data: []byte("olá")},
+		"page.html":       &fstest.MapFile{Data: []byte("default")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.LanguagePattern = regexp.MustCompile(`\.([a-z]{2}(?:-[A-Z]{2})?)\.html$`)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/assets/page.en.html", "en"},
+		{"/assets/page.fr.html", "fr"},
+		{"/assets/page.pt-BR.html", "pt-BR"},
+		{"/assets/page.html", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Header().Get("Content-Language"))
+		})
+	}
+
+	t.Run("Pattern without capture group is ignored", func(t *testing.T) {
+		server.LanguagePattern = regexp.MustCompile(`\.en\.html$`)
+		defer func() { server.LanguagePattern = nil }()
+		req := httptest.NewRequest("GET", "/assets/page.en.html", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, "", w.Header().Get("Content-Language"))
+	})
+}
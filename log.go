@@ -0,0 +1,82 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"time"
+)
+
+// StaticaLogFunc receives one record per served request: the status sent, the number of
+// body bytes written, the Content-Encoding of the response (empty when uncompressed), and
+// how long serving took
+type StaticaLogFunc func(r *http.Request, status int, bytes int, encoding string, dur time.Duration)
+
+// responseRecorder wraps a http.ResponseWriter to capture what was sent through it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(data)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController and Capabilities
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// statusCode is the status sent, or the 200 net/http sends for handlers writing nothing
+func (rec *responseRecorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// observe wraps w so the response can be passed to LogFunc and Metrics once done is called
+func (server *AssetServer) observe(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	if server.LogFunc == nil && server.Metrics == nil {
+		return w, func() {}
+	}
+	if server.Metrics != nil {
+		server.Metrics.RequestStarted(r)
+	}
+	start := time.Now()
+	rec := &responseRecorder{ResponseWriter: w}
+	return rec, func() {
+		status, encoding, dur := rec.statusCode(), rec.Header().Get("Content-Encoding"), time.Since(start)
+		if server.LogFunc != nil {
+			server.LogFunc(r, status, rec.bytes, encoding, dur)
+		}
+		if server.Metrics != nil {
+			server.Metrics.RequestFinished(r, status, rec.bytes, encoding, dur)
+		}
+	}
+}
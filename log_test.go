@@ -0,0 +1,119 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logRecord struct {
+	path     string
+	status   int
+	bytes    int
+	encoding string
+	dur      time.Duration
+}
+
+func newLoggedServer(t *testing.T) (*AssetServer, *[]logRecord) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+	server.BrotliSuffix = ".br"
+	records := &[]logRecord{}
+	server.LogFunc = func(r *http.Request, status int, bytes int, encoding string, dur time.Duration) {
+		*records = append(*records, logRecord{r.URL.Path, status, bytes, encoding, dur})
+	}
+	return server, records
+}
+
+func TestLogFunc(t *testing.T) {
+	t.Run("Successful CSS request", func(t *testing.T) {
+		server, records := newLoggedServer(t)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/test.css", nil))
+
+		require.Len(t, *records, 1)
+		record := (*records)[0]
+		assert.Equal(t, "/assets/test.css", record.path)
+		assert.Equal(t, http.StatusOK, record.status)
+		assert.Equal(t, len(testFiles["test.css"].Data), record.bytes)
+		assert.Empty(t, record.encoding)
+		assert.Positive(t, record.dur)
+	})
+
+	t.Run("Compressed variant", func(t *testing.T) {
+		server, records := newLoggedServer(t)
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		server.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *records, 1)
+		assert.Equal(t, "br", (*records)[0].encoding)
+		assert.Equal(t, len(testFiles["test.css.br"].Data), (*records)[0].bytes)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		server, records := newLoggedServer(t)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/missing.css", nil))
+
+		require.Len(t, *records, 1)
+		assert.Equal(t, http.StatusNotFound, (*records)[0].status)
+		assert.Equal(t, len("Not Found"), (*records)[0].bytes)
+	})
+
+	t.Run("Not modified", func(t *testing.T) {
+		server, records := newLoggedServer(t)
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("If-None-Match", DefaultETagFunc(testFiles["test.css"].Data))
+		server.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *records, 1)
+		assert.Equal(t, http.StatusNotModified, (*records)[0].status)
+		assert.Zero(t, (*records)[0].bytes)
+	})
+
+	t.Run("ServeFile", func(t *testing.T) {
+		server, records := newLoggedServer(t)
+		server.ServeFile("test.txt").ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/robots.txt", nil))
+
+		require.Len(t, *records, 1)
+		assert.Equal(t, "/robots.txt", (*records)[0].path)
+		assert.Equal(t, http.StatusOK, (*records)[0].status)
+	})
+
+	t.Run("Writer capabilities preserved", func(t *testing.T) {
+		server, _ := newLoggedServer(t)
+		w, done := server.observe(httptest.NewRecorder(), httptest.NewRequest("GET", "/assets/test.css", nil))
+		defer done()
+
+		assert.True(t, server.Capabilities(w).Flush)
+	})
+
+	t.Run("Nil LogFunc leaves the writer alone", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		recorder := httptest.NewRecorder()
+		w, done := server.observe(recorder, httptest.NewRequest("GET", "/assets/test.css", nil))
+		done()
+
+		assert.Same(t, recorder, w)
+	})
+}
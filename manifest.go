@@ -0,0 +1,243 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ManifestEntry describes one asset in a manifest
+type ManifestEntry struct {
+	Path        string `json:"path"`
+	Size        int    `json:"size"`
+	ContentType string `json:"contentType"`
+	Integrity   string `json:"integrity"`
+}
+
+// Manifest lists every asset a server can serve
+type Manifest struct {
+	Assets []ManifestEntry `json:"assets"`
+}
+
+// ManifestHandler serves a JSON Manifest of a server's assets for service workers and
+// prefetch logic. The manifest is generated on first use and reused until the TTL
+// elapses or Invalidate is called.
+type ManifestHandler struct {
+	server    *AssetServer
+	ttl       time.Duration
+	mu        sync.Mutex
+	body      []byte
+	generated time.Time
+}
+
+// NewManifestHandler creates a ManifestHandler for server. The manifest is regenerated
+// when it is older than ttl; zero or negative keeps it until Invalidate is called. Mount
+// it at a path of your choosing, e.g. mux.Handle("/assets/manifest.json", handler).
+func (server *AssetServer) NewManifestHandler(ttl time.Duration) *ManifestHandler {
+	return &ManifestHandler{server: server, ttl: ttl}
+}
+
+// Invalidate discards the current manifest so the next request regenerates it.
+// Safe for concurrent use.
+func (handler *ManifestHandler) Invalidate() {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	handler.body = nil
+}
+
+// ServeHTTP responds with the manifest, generating it when needed
+func (handler *ManifestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server := handler.server
+	if !server.methodAllowed(w, r) {
+		return
+	}
+	body, err := handler.manifest()
+	if err != nil {
+		server.fail(w, r, err)
+		return
+	}
+	// not "manifest.json", which is typed as a web app manifest
+	server.writeAsset(w, r, "asset-manifest.json", body, "", time.Time{})
+}
+
+// manifest returns the encoded manifest, regenerating it when missing or expired
+func (handler *ManifestHandler) manifest() ([]byte, error) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if handler.body != nil && (handler.ttl <= 0 || time.Since(handler.generated) < handler.ttl) {
+		return handler.body, nil
+	}
+	manifest, err := handler.server.buildManifest()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	handler.body, handler.generated = body, time.Now()
+	return body, nil
+}
+
+// buildManifest reads every asset to describe it
+func (server *AssetServer) buildManifest() (Manifest, error) {
+	manifest := Manifest{Assets: []ManifestEntry{}}
+	err := server.walkAssets(false, func(assetPath string) error {
+		data, err := server.readFS(context.Background(), server.fsPath(assetPath))
+		if err != nil {
+			return err
+		}
+		// Integrity covers the transformed content, so the manifest agrees with templates
+		integrity, err := server.Integrity(assetPath)
+		if err != nil {
+			return err
+		}
+		manifest.Assets = append(manifest.Assets, ManifestEntry{
+			Path:        assetPath,
+			Size:        len(data),
+			ContentType: server.inferMimeType(assetPath),
+			Integrity:   integrity,
+		})
+		return nil
+	})
+	return manifest, err
+}
+
+// PrecacheEntry is one URL of a service worker precache manifest
+type PrecacheEntry struct {
+	URL      string `json:"url"`
+	Revision string `json:"revision"`
+}
+
+// PrecacheManifest returns a JSON array of every asset URL, under the server's route, with
+// a content hash revision, in the format used by Workbox and similar service worker
+// tooling. Serve assets with PrecacheHeaderFunc so the service worker, rather than the
+// HTTP cache, decides when they are refreshed.
+func (server *AssetServer) PrecacheManifest() ([]byte, error) {
+	entries := []PrecacheEntry{}
+	err := server.walkAssets(false, func(assetPath string) error {
+		data, err := server.readFS(context.Background(), server.fsPath(assetPath))
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		entries = append(entries, PrecacheEntry{
+			URL:      path.Join("/", server.route, assetPath),
+			Revision: hex.EncodeToString(sum[:16]),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(entries)
+}
+
+// PrecacheHeaderFunc sets "Cache-Control: no-cache" so browsers revalidate every request
+// and a service worker's precache stays the only long-lived copy of assets
+func PrecacheHeaderFunc(w http.ResponseWriter, data []byte) {
+	w.Header().Add("Cache-Control", "no-cache")
+}
+
+// walkAssets calls fn, in lexical order of the files walked, with the route-relative path
+// of every asset under FSPrefix a client can request. Files refused by HideDotfiles,
+// StrictFilenames, DisabledVariants, or FollowSymlinks are skipped. Precompressed
+// variants are skipped since they are served in place of their originals, unless
+// collapseVariants is set, in which case each is passed once under the name it is
+// served as.
+func (server *AssetServer) walkAssets(collapseVariants bool, fn func(assetPath string) error) error {
+	root := strings.TrimSuffix(server.FSPrefix, "/")
+	if root == "" {
+		root = "."
+	}
+	seen := map[string]bool{}
+	return fs.WalkDir(server.files, root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// refused when read, or a linked directory WalkDir does not descend into
+			if !server.FollowSymlinks {
+				return nil
+			}
+			if info, err := fs.Stat(server.files, filePath); err != nil || info.IsDir() {
+				return nil
+			}
+		}
+		assetPath := strings.TrimPrefix(filePath, server.FSPrefix)
+		if encoding := server.directEncoding(assetPath); encoding != "" {
+			if !collapseVariants {
+				return nil
+			}
+			assetPath = strings.TrimSuffix(assetPath, server.variantSuffix(encoding))
+		} else if original, ok := server.disabledVariant(assetPath); ok {
+			switch server.DisabledVariants {
+			case DisabledVariantNotFound:
+				return nil
+			case DisabledVariantOriginal:
+				if !collapseVariants {
+					return nil
+				}
+				assetPath = original
+			}
+		}
+		if server.StrictFilenames && !portablePath(assetPath) || server.HideDotfiles && hiddenPath(assetPath) {
+			return nil
+		}
+		if seen[assetPath] {
+			return nil
+		}
+		seen[assetPath] = true
+		return fn(assetPath)
+	})
+}
+
+// integrityHash returns the Subresource Integrity value of data
+func integrityHash(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ListAssets returns, in lexical order, the route-relative path of every asset a client
+// can request, e.g. for generating sitemaps. Precompressed variants are listed under the
+// name of the asset they are served as, whether or not the original exists. Files refused
+// by HideDotfiles, StrictFilenames, DisabledVariants, or FollowSymlinks are left out.
+func (server *AssetServer) ListAssets() ([]string, error) {
+	assets := []string{}
+	err := server.walkAssets(true, func(assetPath string) error {
+		assets = append(assets, assetPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(assets)
+	return assets, nil
+}
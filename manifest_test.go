@@ -0,0 +1,297 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var manifestTestFiles = fstest.MapFS{
+	"dist/app.js":        &fstest.MapFile{Data: []byte("console.log(1);")},
+	"dist/app.js.br":     &fstest.MapFile{Data: []byte("compressed")},
+	"dist/css/site.css":  &fstest.MapFile{Data: []byte("body{}")},
+	"other/ignored.html": &fstest.MapFile{Data: []byte("<p>outside prefix</p>")},
+}
+
+func fetchManifest(t *testing.T, handler http.Handler) Manifest {
+	req := httptest.NewRequest("GET", "/assets/manifest.json", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, utf8Type(mimeTypeJSON), w.Header().Get("Content-Type"))
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &manifest))
+	return manifest
+}
+
+func TestIntegrityHash(t *testing.T) {
+	// Matches: printf "%s" "alert('Hello, world.');" | openssl dgst -sha384 -binary | openssl base64 -A
+	assert.Equal(t, "sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO", integrityHash([]byte("alert('Hello, world.');")))
+}
+
+func TestManifestHandler(t *testing.T) {
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", manifestTestFiles)
+		require.Nil(t, err)
+		server.FSPrefix = "dist/"
+		server.BrotliSuffix = ".br"
+		return server
+	}
+
+	t.Run("Lists assets under FSPrefix", func(t *testing.T) {
+		manifest := fetchManifest(t, newServer(t).NewManifestHandler(0))
+
+		assert.Equal(t, []ManifestEntry{
+			{Path: "app.js", Size: 15, ContentType: utf8Type(mimeTypeJS), Integrity: integrityHash([]byte("console.log(1);"))},
+			{Path: "css/site.css", Size: 6, ContentType: utf8Type(mimeTypeCSS), Integrity: integrityHash([]byte("body{}"))},
+		}, manifest.Assets)
+	})
+
+	t.Run("Integrity follows Transforms", func(t *testing.T) {
+		files := fstest.MapFS{"a.css": &fstest.MapFile{Data: []byte("a{}\r\nb{}\r\n")}}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.Transforms = []StaticaTransformFunc{NormalizeLineEndings(LineEndingsLF)}
+
+		manifest := fetchManifest(t, server.NewManifestHandler(0))
+
+		require.Len(t, manifest.Assets, 1)
+		integrity, err := server.Integrity("a.css")
+		require.NoError(t, err)
+		assert.Equal(t, integrity, manifest.Assets[0].Integrity)
+		assert.Equal(t, sri("a{}\nb{}\n"), manifest.Assets[0].Integrity)
+	})
+
+	t.Run("Empty filesystem", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", fstest.MapFS{})
+		require.Nil(t, err)
+		manifest := fetchManifest(t, server.NewManifestHandler(0))
+		assert.Empty(t, manifest.Assets)
+	})
+
+	t.Run("Generated once until invalidated", func(t *testing.T) {
+		files := fstest.MapFS{"a.css": &fstest.MapFile{Data: []byte("a{}")}}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		handler := server.NewManifestHandler(0)
+		require.Len(t, fetchManifest(t, handler).Assets, 1)
+
+		files["b.css"] = &fstest.MapFile{Data: []byte("b{}")}
+		assert.Len(t, fetchManifest(t, handler).Assets, 1)
+
+		handler.Invalidate()
+		assert.Len(t, fetchManifest(t, handler).Assets, 2)
+	})
+
+	t.Run("Regenerated after TTL", func(t *testing.T) {
+		files := fstest.MapFS{"a.css": &fstest.MapFile{Data: []byte("a{}")}}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		handler := server.NewManifestHandler(time.Millisecond)
+		require.Len(t, fetchManifest(t, handler).Assets, 1)
+
+		files["b.css"] = &fstest.MapFile{Data: []byte("b{}")}
+		time.Sleep(5 * time.Millisecond)
+		assert.Len(t, fetchManifest(t, handler).Assets, 2)
+	})
+
+	t.Run("Refused files left out", func(t *testing.T) {
+		files := fstest.MapFS{
+			"a.css":          &fstest.MapFile{Data: []byte("a{}")},
+			".env":           &fstest.MapFile{Data: []byte("SECRET=1")},
+			".git/config":    &fstest.MapFile{Data: []byte("[core]")},
+			"con.css":        &fstest.MapFile{Data: []byte("c{}")},
+			"draft.css.":     &fstest.MapFile{Data: []byte("d{}")},
+			"legacy/nul.txt": &fstest.MapFile{Data: []byte("n")},
+		}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.HideDotfiles = true
+		server.StrictFilenames = true
+
+		manifest := fetchManifest(t, server.NewManifestHandler(0))
+
+		require.Len(t, manifest.Assets, 1)
+		assert.Equal(t, "a.css", manifest.Assets[0].Path)
+	})
+
+	t.Run("Method restrictions apply", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/assets/manifest.json", nil)
+		w := httptest.NewRecorder()
+
+		newServer(t).NewManifestHandler(0).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestPrecacheManifest(t *testing.T) {
+	t.Run("Lists URLs with revisions", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", manifestTestFiles)
+		require.Nil(t, err)
+		server.FSPrefix = "dist/"
+		server.BrotliSuffix = ".br"
+
+		data, err := server.PrecacheManifest()
+		require.NoError(t, err)
+		var entries []PrecacheEntry
+		require.NoError(t, json.Unmarshal(data, &entries))
+		require.Len(t, entries, 2)
+		assert.Equal(t, "/assets/app.js", entries[0].URL)
+		assert.Equal(t, "/assets/css/site.css", entries[1].URL)
+		assert.Len(t, entries[0].Revision, 32)
+		assert.NotEqual(t, entries[0].Revision, entries[1].Revision)
+	})
+
+	t.Run("Revision follows content", func(t *testing.T) {
+		files := fstest.MapFS{"app.js": &fstest.MapFile{Data: []byte("v1")}}
+		server, err := NewAssetServer("/", files)
+		require.Nil(t, err)
+
+		before, err := server.PrecacheManifest()
+		require.NoError(t, err)
+		assert.Contains(t, string(before), `"url":"/app.js"`)
+		files["app.js"] = &fstest.MapFile{Data: []byte("v2")}
+		after, err := server.PrecacheManifest()
+		require.NoError(t, err)
+		assert.NotEqual(t, before, after)
+	})
+
+	t.Run("Refused files left out", func(t *testing.T) {
+		files := fstest.MapFS{
+			"app.js":  &fstest.MapFile{Data: []byte("app()")},
+			".env":    &fstest.MapFile{Data: []byte("SECRET=1")},
+			"aux.js":  &fstest.MapFile{Data: []byte("aux()")},
+			"old.js ": &fstest.MapFile{Data: []byte("old()")},
+		}
+		server, err := NewAssetServer("/", files)
+		require.Nil(t, err)
+		server.HideDotfiles = true
+		server.StrictFilenames = true
+
+		data, err := server.PrecacheManifest()
+		require.NoError(t, err)
+		var entries []PrecacheEntry
+		require.NoError(t, json.Unmarshal(data, &entries))
+		require.Len(t, entries, 1)
+		assert.Equal(t, "/app.js", entries[0].URL)
+	})
+
+	t.Run("Empty filesystem", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", fstest.MapFS{})
+		require.Nil(t, err)
+		data, err := server.PrecacheManifest()
+		require.NoError(t, err)
+		assert.Equal(t, "[]", string(data))
+	})
+}
+
+func TestPrecacheHeaderFunc(t *testing.T) {
+	w := httptest.NewRecorder()
+	PrecacheHeaderFunc(w, nil)
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+}
+
+func TestListAssets(t *testing.T) {
+	files := fstest.MapFS{
+		"public/index.html":            &fstest.MapFile{Data: []byte("<html></html>")},
+		"public/app.js":                &fstest.MapFile{Data: []byte("console.log(1);")},
+		"public/app.js.br":             &fstest.MapFile{Data: []byte("br")},
+		"public/app.js.zst":            &fstest.MapFile{Data: []byte("zst")},
+		"public/css/site.css":          &fstest.MapFile{Data: []byte("body{}")},
+		"public/css/vendor/reset.css":  &fstest.MapFile{Data: []byte("*{}")},
+		"public/data/large.json.br":    &fstest.MapFile{Data: []byte("br only")},
+		"public/.well-known/security":  &fstest.MapFile{Data: []byte("contact")},
+		"private/secrets.txt":          &fstest.MapFile{Data: []byte("outside prefix")},
+		"public/img/logo.svg":          &fstest.MapFile{Data: []byte("<svg/>")},
+		"public/img/icons/favicon.ico": &fstest.MapFile{Data: []byte("ico")},
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/static/", files)
+		require.NoError(t, err)
+		server.FSPrefix = "public/"
+		server.BrotliSuffix = ".br"
+		server.ZstdSuffix = ".zst"
+		return server
+	}
+
+	t.Run("Lists route-relative paths with variants collapsed", func(t *testing.T) {
+		assets, err := newServer(t).ListAssets()
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			".well-known/security",
+			"app.js",
+			"css/site.css",
+			"css/vendor/reset.css",
+			"data/large.json",
+			"img/icons/favicon.ico",
+			"img/logo.svg",
+			"index.html",
+		}, assets)
+	})
+
+	t.Run("Omits dotfiles when hidden", func(t *testing.T) {
+		server := newServer(t)
+		server.HideDotfiles = true
+		assets, err := server.ListAssets()
+		require.NoError(t, err)
+		assert.NotContains(t, assets, ".well-known/security")
+		assert.Len(t, assets, 7)
+	})
+
+	t.Run("Lists variant files as-is when their suffix is unset", func(t *testing.T) {
+		server := newServer(t)
+		server.ZstdSuffix = ""
+		assets, err := server.ListAssets()
+		require.NoError(t, err)
+		assert.Contains(t, assets, "app.js.zst")
+		assert.Contains(t, assets, "app.js")
+	})
+
+	t.Run("Omits disabled variants that are not found", func(t *testing.T) {
+		server := newServer(t)
+		server.ZstdSuffix = ""
+		server.DisabledVariants = DisabledVariantNotFound
+		assets, err := server.ListAssets()
+		require.NoError(t, err)
+		assert.NotContains(t, assets, "app.js.zst")
+		assert.Contains(t, assets, "app.js")
+	})
+
+	t.Run("Lists the whole filesystem without a prefix", func(t *testing.T) {
+		server := newServer(t)
+		server.FSPrefix = ""
+		assets, err := server.ListAssets()
+		require.NoError(t, err)
+		assert.Contains(t, assets, "private/secrets.txt")
+		assert.Contains(t, assets, "public/app.js")
+	})
+
+	t.Run("Missing prefix", func(t *testing.T) {
+		server := newServer(t)
+		server.FSPrefix = "missing/"
+		_, err := server.ListAssets()
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
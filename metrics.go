@@ -0,0 +1,37 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"time"
+)
+
+// MetricsCollector receives events from AssetServer and CachingFS so they can be exported
+// to a metrics system, such as Prometheus counters and histograms, without this package
+// depending on one. Implementations must be safe for concurrent use.
+type MetricsCollector interface {
+	// RequestStarted is called when ServeHTTP or ServeFile begins serving r
+	RequestStarted(r *http.Request)
+	// RequestFinished is called once r has been answered, with the status sent, the
+	// number of body bytes written, the Content-Encoding of the response (empty when
+	// uncompressed), and how long serving took
+	RequestFinished(r *http.Request, status int, bytes int, encoding string, dur time.Duration)
+	// CacheHit is called when CachingFS answers a read of filePath, including a
+	// remembered miss, without reaching the underlying filesystem
+	CacheHit(filePath string)
+	// CacheMiss is called when a read of filePath reaches the underlying filesystem
+	CacheMiss(filePath string)
+}
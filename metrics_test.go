@@ -0,0 +1,127 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCollector struct {
+	mu        sync.Mutex
+	started   []string
+	finished  []string
+	statuses  map[int]int
+	bytes     int
+	encodings map[string]int
+	hits      []string
+	misses    []string
+}
+
+func newFakeCollector() *fakeCollector {
+	return &fakeCollector{statuses: map[int]int{}, encodings: map[string]int{}}
+}
+
+func (c *fakeCollector) RequestStarted(r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.started = append(c.started, r.URL.Path)
+}
+
+func (c *fakeCollector) RequestFinished(r *http.Request, status int, bytes int, encoding string, dur time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finished = append(c.finished, r.URL.Path)
+	c.statuses[status]++
+	c.bytes += bytes
+	c.encodings[encoding]++
+}
+
+func (c *fakeCollector) CacheHit(filePath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hits = append(c.hits, filePath)
+}
+
+func (c *fakeCollector) CacheMiss(filePath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.misses = append(c.misses, filePath)
+}
+
+func TestMetricsCollector(t *testing.T) {
+	t.Run("Requests", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		collector := newFakeCollector()
+		server.Metrics = collector
+
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/assets/test.css", nil))
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		server.ServeHTTP(httptest.NewRecorder(), req)
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/assets/missing.css", nil))
+
+		expectedPaths := []string{"/assets/test.css", "/assets/test.css", "/assets/missing.css"}
+		assert.Equal(t, expectedPaths, collector.started)
+		assert.Equal(t, expectedPaths, collector.finished)
+		assert.Equal(t, map[int]int{http.StatusOK: 2, http.StatusNotFound: 1}, collector.statuses)
+		assert.Equal(t, map[string]int{"": 2, "br": 1}, collector.encodings)
+		expectedBytes := len(testFiles["test.css"].Data) + len(testFiles["test.css.br"].Data) + len("Not Found")
+		assert.Equal(t, expectedBytes, collector.bytes)
+	})
+
+	t.Run("Cache hits and misses", func(t *testing.T) {
+		collector := newFakeCollector()
+		cfs, err := NewCachingFS(fstest.MapFS{
+			"app.js": &fstest.MapFile{Data: []byte("console.log('app')")},
+		}, &CachingFSOption{CacheMisses: true, Metrics: collector})
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			_, err = cfs.ReadFile("app.js")
+			require.NoError(t, err)
+			_, err = cfs.ReadFile("missing.js")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+		}
+
+		assert.Equal(t, []string{"app.js", "missing.js"}, collector.misses)
+		assert.Equal(t, []string{"app.js", "missing.js"}, collector.hits)
+	})
+
+	t.Run("Disabled cache counts misses", func(t *testing.T) {
+		collector := newFakeCollector()
+		cfs, err := NewCachingFS(testFiles, &CachingFSOption{Metrics: collector})
+		require.NoError(t, err)
+		cfs.SetEnabled(false)
+
+		_, err = cfs.ReadFile("test.css")
+		require.NoError(t, err)
+		_, err = cfs.ReadFile("test.css")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"test.css", "test.css"}, collector.misses)
+		assert.Empty(t, collector.hits)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import "net/http"
+
+// Security header values sent by SecurityHeaders
+const (
+	// NoSniff stops browsers guessing a content type other than the one sent
+	NoSniff = "nosniff"
+	// FrameOptionsSameOrigin only lets pages of the same origin frame assets
+	FrameOptionsSameOrigin = "SAMEORIGIN"
+	// DefaultReferrerPolicy sends full referrers within an origin and only the origin
+	// across origins, dropping it on downgrades to http
+	DefaultReferrerPolicy = "strict-origin-when-cross-origin"
+)
+
+// SecurityHeaders is middleware setting X-Content-Type-Options: nosniff, X-Frame-Options:
+// SAMEORIGIN, and Referrer-Policy: strict-origin-when-cross-origin before calling next,
+// so handlers further in, such as HeaderFunc, can still replace them
+func SecurityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("X-Content-Type-Options", NoSniff)
+		header.Set("X-Frame-Options", FrameOptionsSameOrigin)
+		header.Set("Referrer-Policy", DefaultReferrerPolicy)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// applyMiddleware wraps handler in the server's Middleware, the first outermost
+func (server *AssetServer) applyMiddleware(handler http.Handler) http.Handler {
+	for i := len(server.Middleware) - 1; i >= 0; i-- {
+		handler = server.Middleware[i](handler)
+	}
+	return handler
+}
+
+// withMiddleware returns handler run inside the Middleware configured when each request
+// arrives, so middleware added after the handler is created still applies
+func (server *AssetServer) withMiddleware(handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.applyMiddleware(handler).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	get := func(handler http.Handler, p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+		return w
+	}
+
+	t.Run("Served asset", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithSecurityHeaders())
+		require.NoError(t, err)
+		w := get(server, "/assets/test.css")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+		assert.Equal(t, testFiles["test.css"].Data, w.Body.Bytes())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithSecurityHeaders())
+		require.NoError(t, err)
+		w := get(server, "/assets/missing.css")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	})
+
+	t.Run("ServeFile and BundleHandler", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithSecurityHeaders())
+		require.NoError(t, err)
+
+		w := get(server.ServeFile("test.js"), "/anything")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+		w = get(server.BundleHandler(), "/assets/bundle?files=test.css")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	})
+
+	t.Run("HeaderFunc can replace them", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithSecurityHeaders())
+		require.NoError(t, err)
+		server.HeaderFunc = func(w http.ResponseWriter, data []byte) {
+			w.Header().Set("X-Frame-Options", "DENY")
+		}
+		w := get(server, "/assets/test.css")
+
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	})
+
+	t.Run("Not sent without the option", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.NoError(t, err)
+		assert.Empty(t, get(server, "/assets/test.css").Header().Get("X-Content-Type-Options"))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Order", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	t.Run("Applied in order, first outermost", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithMiddleware(tag("a"), tag("b")), WithMiddleware(tag("c")))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/test.css", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"a", "b", "c"}, w.Header().Values("X-Order"))
+	})
+
+	t.Run("Middleware can answer requests itself", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.NoError(t, err)
+		server.Middleware = append(server.Middleware, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") == "" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		})
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/test.css", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Request changes reach the server", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.NoError(t, err)
+		server.Middleware = append(server.Middleware, func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rewritten := r.Clone(r.Context())
+				rewritten.URL.Path = strings.Replace(r.URL.Path, "/legacy/", "/assets/", 1)
+				next.ServeHTTP(w, rewritten)
+			})
+		})
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/legacy/test.css", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Middleware added after ServeFile applies", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.NoError(t, err)
+		handler := server.ServeFile("test.css")
+		server.Middleware = append(server.Middleware, tag("late"))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, []string{"late"}, w.Header().Values("X-Order"))
+	})
+}
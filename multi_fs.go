@@ -0,0 +1,112 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// MultiFSEntry is one layer of a MultiFS
+type MultiFSEntry struct {
+	FS fs.ReadFileFS
+	// Prefix is prepended to paths before they are looked up in FS, e.g. "theme/".
+	// It follows the same rules as AssetServer.FSPrefix.
+	Prefix string
+}
+
+// MultiFS layers several filesystems. Lookups try each entry in order and the first
+// result other than fs.ErrNotExist wins, so earlier entries override later ones.
+type MultiFS struct {
+	entries []MultiFSEntry
+}
+
+var _ fs.ReadFileFS = (*MultiFS)(nil)
+
+// NewMultiFS creates a new MultiFS instance from entries, highest precedence first
+func NewMultiFS(entries ...MultiFSEntry) (*MultiFS, error) {
+	if len(entries) == 0 {
+		return nil, ErrNilFS
+	}
+	for _, entry := range entries {
+		if entry.FS == nil {
+			return nil, ErrNilFS
+		}
+		if entry.Prefix != "" {
+			if strings.HasPrefix(entry.Prefix, "/") {
+				return nil, ErrAbsoluteFSPrefix
+			}
+			if !strings.HasSuffix(entry.Prefix, "/") {
+				return nil, ErrBadFSPrefix
+			}
+		}
+	}
+	return &MultiFS{
+		entries: append([]MultiFSEntry(nil), entries...),
+	}, nil
+}
+
+// NewCompositeFS layers unprefixed filesystems, highest precedence first, e.g. on-disk
+// overrides before the embedded assets they fall back to. It is shorthand for NewMultiFS
+// with an entry for each filesystem.
+func NewCompositeFS(layers ...fs.ReadFileFS) (*MultiFS, error) {
+	entries := make([]MultiFSEntry, len(layers))
+	for i, layer := range layers {
+		entries[i] = MultiFSEntry{FS: layer}
+	}
+	return NewMultiFS(entries...)
+}
+
+// entryPath maps name to its location within an entry's filesystem
+func (entry *MultiFSEntry) entryPath(name string) string {
+	if entry.Prefix == "" {
+		return name
+	}
+	if name == "." {
+		return strings.TrimSuffix(entry.Prefix, "/")
+	}
+	return entry.Prefix + name
+}
+
+// Open opens name from the first entry which has it
+func (mfs *MultiFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for i := range mfs.entries {
+		entry := &mfs.entries[i]
+		file, err := entry.FS.Open(entry.entryPath(name))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return file, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadFile reads name from the first entry which has it
+func (mfs *MultiFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	for i := range mfs.entries {
+		entry := &mfs.entries[i]
+		data, err := entry.FS.ReadFile(entry.entryPath(name))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return data, err
+		}
+	}
+	return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+}
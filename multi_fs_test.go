@@ -0,0 +1,190 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	themeFiles = fstest.MapFS{
+		"theme/style.css":   &fstest.MapFile{Data: []byte("theme css")},
+		"theme/header.html": &fstest.MapFile{Data: []byte("theme header")},
+	}
+	pluginFiles = fstest.MapFS{
+		"style.css":  &fstest.MapFile{Data: []byte("plugin css")},
+		"plugin.js":  &fstest.MapFile{Data: []byte("plugin js")},
+		"theme/x.js": &fstest.MapFile{Data: []byte("not under theme prefix")},
+	}
+)
+
+func TestNewMultiFS(t *testing.T) {
+	t.Run("Valid entries", func(t *testing.T) {
+		mfs, err := NewMultiFS(MultiFSEntry{FS: themeFiles, Prefix: "theme/"}, MultiFSEntry{FS: pluginFiles})
+		require.NoError(t, err)
+		assert.NotNil(t, mfs)
+	})
+
+	t.Run("No entries", func(t *testing.T) {
+		mfs, err := NewMultiFS()
+		assert.Nil(t, mfs)
+		assert.Equal(t, ErrNilFS, err)
+	})
+
+	t.Run("Nil filesystem", func(t *testing.T) {
+		mfs, err := NewMultiFS(MultiFSEntry{FS: themeFiles}, MultiFSEntry{})
+		assert.Nil(t, mfs)
+		assert.Equal(t, ErrNilFS, err)
+	})
+
+	t.Run("Bad prefixes", func(t *testing.T) {
+		_, err := NewMultiFS(MultiFSEntry{FS: themeFiles, Prefix: "/theme/"})
+		assert.Equal(t, ErrAbsoluteFSPrefix, err)
+		_, err = NewMultiFS(MultiFSEntry{FS: themeFiles, Prefix: "theme"})
+		assert.Equal(t, ErrBadFSPrefix, err)
+	})
+}
+
+func TestMultiFS(t *testing.T) {
+	mfs, err := NewMultiFS(MultiFSEntry{FS: themeFiles, Prefix: "theme/"}, MultiFSEntry{FS: pluginFiles})
+	require.NoError(t, err)
+
+	t.Run("First entry wins", func(t *testing.T) {
+		data, err := mfs.ReadFile("style.css")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("theme css"), data)
+	})
+
+	t.Run("Falls through to later entries", func(t *testing.T) {
+		data, err := mfs.ReadFile("plugin.js")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("plugin js"), data)
+	})
+
+	t.Run("Prefix is applied per entry", func(t *testing.T) {
+		data, err := mfs.ReadFile("header.html")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("theme header"), data)
+
+		data, err = mfs.ReadFile("theme/x.js")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("not under theme prefix"), data)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		data, err := mfs.ReadFile("missing.css")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+		assert.Nil(t, data)
+	})
+
+	t.Run("Invalid path", func(t *testing.T) {
+		_, err := mfs.ReadFile("../style.css")
+		assert.True(t, errors.Is(err, fs.ErrInvalid))
+		_, err = mfs.Open("/style.css")
+		assert.True(t, errors.Is(err, fs.ErrInvalid))
+	})
+
+	t.Run("Other errors stop the search", func(t *testing.T) {
+		mfs, err := NewMultiFS(MultiFSEntry{FS: errorFS{}}, MultiFSEntry{FS: pluginFiles})
+		require.NoError(t, err)
+		_, err = mfs.ReadFile("permission_error")
+		assert.True(t, errors.Is(err, fs.ErrPermission))
+	})
+
+	t.Run("Open follows the same precedence", func(t *testing.T) {
+		file, err := mfs.Open("style.css")
+		require.NoError(t, err)
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("theme css"), data)
+
+		_, err = mfs.Open("missing.css")
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("Open root directory", func(t *testing.T) {
+		file, err := mfs.Open(".")
+		require.NoError(t, err)
+		defer file.Close()
+		info, err := file.Stat()
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+	})
+
+	t.Run("Served by AssetServer", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", mfs)
+		require.Nil(t, err)
+		req := httptest.NewRequest("GET", "/assets/style.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "theme css", w.Body.String())
+	})
+}
+
+func TestNewCompositeFS(t *testing.T) {
+	base := fstest.MapFS{
+		"style.css": &fstest.MapFile{Data: []byte("base css")},
+		"app.js":    &fstest.MapFile{Data: []byte("base js")},
+	}
+	overrides := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(overrides, "style.css"), []byte("override css"), 0644))
+	composite, err := NewCompositeFS(AdaptFS(os.DirFS(overrides)), base)
+	require.NoError(t, err)
+	server, err := NewAssetServer("/static/", composite)
+	require.Nil(t, err)
+	get := func(urlPath string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", urlPath, nil))
+		return w
+	}
+
+	t.Run("Override wins", func(t *testing.T) {
+		w := get("/static/style.css")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "override css", w.Body.String())
+	})
+
+	t.Run("Falls back to the base", func(t *testing.T) {
+		w := get("/static/app.js")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "base js", w.Body.String())
+	})
+
+	t.Run("Missing from every layer", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/static/missing.js").Code)
+	})
+
+	t.Run("Requires a filesystem", func(t *testing.T) {
+		_, err := NewCompositeFS()
+		assert.Equal(t, ErrNilFS, err)
+		_, err = NewCompositeFS(base, nil)
+		assert.Equal(t, ErrNilFS, err)
+	})
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var ErrBadMimeMapping = errors.New("mime type mapping has an empty extension or mime type")
+var ErrNilErrFunc = errors.New("error func is nil")
+var ErrMimeTypeRefused = errors.New("mime type registration was refused")
+
+// Option configures an AssetServer created by NewAssetServerWithOptions
+type Option func(server *AssetServer) error
+
+// NewAssetServerWithOptions creates a new AssetServer instance and applies opts in order.
+// The first option to fail aborts construction and its error is returned.
+func NewAssetServerWithOptions(route string, files fs.FS, opts ...Option) (*AssetServer, error) {
+	server, err := NewAssetServer(route, files)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		if err := opt(server); err != nil {
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+// WithFSPrefix sets FSPrefix, which must be relative and end with '/'
+func WithFSPrefix(prefix string) Option {
+	return func(server *AssetServer) error {
+		if strings.HasPrefix(prefix, "/") {
+			return ErrAbsoluteFSPrefix
+		}
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			return ErrBadFSPrefix
+		}
+		server.FSPrefix = prefix
+		return nil
+	}
+}
+
+// WithBrotliSuffix sets BrotliSuffix, which must start with '.' or be empty to disable
+// Brotli variants
+func WithBrotliSuffix(suffix string) Option {
+	return func(server *AssetServer) error {
+		if suffix != "" && !strings.HasPrefix(suffix, ".") {
+			return ErrBadBrotliSuffix
+		}
+		server.BrotliSuffix = suffix
+		return nil
+	}
+}
+
+// WithGzipLevel sets GzipLevel, which must be a compress/gzip level from
+// gzip.HuffmanOnly to gzip.BestCompression
+func WithGzipLevel(level int) Option {
+	return func(server *AssetServer) error {
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			return ErrBadGzipLevel
+		}
+		server.GzipLevel = level
+		return nil
+	}
+}
+
+// WithErrFunc sets ErrFunc. A nil func is rejected since errors would then send empty
+// 200 responses.
+func WithErrFunc(errFunc StaticaErrFunc) Option {
+	return func(server *AssetServer) error {
+		if errFunc == nil {
+			return ErrNilErrFunc
+		}
+		server.ErrFunc = errFunc
+		return nil
+	}
+}
+
+// WithHeaderFunc sets HeaderFunc. A nil func sets no extra headers.
+func WithHeaderFunc(headerFunc StaticaHeaderFunc) Option {
+	return func(server *AssetServer) error {
+		server.HeaderFunc = headerFunc
+		return nil
+	}
+}
+
+// WithMiddleware appends middleware to Middleware, in order, so earlier middleware is
+// further out
+func WithMiddleware(middleware ...func(http.Handler) http.Handler) Option {
+	return func(server *AssetServer) error {
+		server.Middleware = append(server.Middleware, middleware...)
+		return nil
+	}
+}
+
+// WithSecurityHeaders appends SecurityHeaders to Middleware
+func WithSecurityHeaders() Option {
+	return WithMiddleware(SecurityHeaders)
+}
+
+// WithPrefixStripped sets PrefixStripped, for servers mounted behind http.StripPrefix or
+// a router which strips its mount path
+func WithPrefixStripped() Option {
+	return func(server *AssetServer) error {
+		server.PrefixStripped = true
+		return nil
+	}
+}
+
+// WithMimeType registers a mime type like RegisterMimeType, returning ErrMimeTypeRefused
+// where RegisterMimeType would return false
+func WithMimeType(expr *regexp.Regexp, mimeType string, priority bool) Option {
+	return func(server *AssetServer) error {
+		if expr == nil || mimeType == "" {
+			return ErrBadMimeMapping
+		}
+		if !server.RegisterMimeType(expr, mimeType, priority) {
+			return fmt.Errorf("%w: %s", ErrMimeTypeRefused, mimeType)
+		}
+		return nil
+	}
+}
+
+// WithMimeTypes registers a map of file extensions to mime types, e.g. {"svg": "image/svg+xml"}.
+// Extensions may be given with or without a leading dot. Unlike RegisterMimeType, several
+// extensions may map to the same mime type. The resulting typers are ordered longest extension
+// first, then alphabetically, so that "tar.gz" is checked before "gz". When replaceDefaults is
+// true the built-in typers are discarded, otherwise the map is checked before them. Fails
+// with ErrTooManyTypers when the result would exceed MaxTypers.
+func WithMimeTypes(m map[string]string, replaceDefaults bool) Option {
+	return func(server *AssetServer) error {
+		extensions := make([]string, 0, len(m))
+		for ext, mimeType := range m {
+			if strings.TrimPrefix(ext, ".") == "" || mimeType == "" {
+				return ErrBadMimeMapping
+			}
+			extensions = append(extensions, ext)
+		}
+		sort.Slice(extensions, func(i, j int) bool {
+			a := strings.TrimPrefix(extensions[i], ".")
+			b := strings.TrimPrefix(extensions[j], ".")
+			if len(a) != len(b) {
+				return len(a) > len(b)
+			}
+			return a < b
+		})
+		typers := make([]mimeTyper, 0, len(extensions)+len(server.typers))
+		for _, ext := range extensions {
+			typers = append(typers, mimeTyper{
+				expr:     extensionRegex(ext),
+				mimeType: m[ext],
+			})
+		}
+		if !replaceDefaults {
+			typers = append(typers, server.typers...)
+		}
+		if server.exceedsMaxTypers(len(typers)) {
+			return fmt.Errorf("registering mime types: %w", ErrTooManyTypers)
+		}
+		server.typers = typers
+		return nil
+	}
+}
+
+// extensionRegex builds a typer pattern matching paths ending in ext
+func extensionRegex(ext string) *regexp.Regexp {
+	return regexp.MustCompile(`\.` + regexp.QuoteMeta(strings.TrimPrefix(ext, ".")) + `$`)
+}
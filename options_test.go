@@ -0,0 +1,184 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAssetServerWithOptions(t *testing.T) {
+	t.Run("No options matches NewAssetServer", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.Equal(t, "/assets/", server.route)
+		assert.Equal(t, len(buildDefaultTypers()), len(server.typers))
+		assert.NotNil(t, server.ErrFunc)
+	})
+
+	t.Run("Constructor errors are returned", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("", testFiles)
+		assert.Nil(t, server)
+		assert.Equal(t, ErrEmptyRoute, err)
+	})
+
+	t.Run("Failing option aborts construction", func(t *testing.T) {
+		optErr := errors.New("option failed")
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, func(*AssetServer) error {
+			return optErr
+		})
+		assert.Nil(t, server)
+		assert.Equal(t, optErr, err)
+	})
+}
+
+func TestWithMimeTypes(t *testing.T) {
+	mimeTypes := map[string]string{
+		"yaml":   "application/yaml",
+		".yml":   "application/yaml",
+		"gz":     "application/gzip",
+		"tar.gz": "application/x-gtar",
+		"css":    "text/x-custom-css",
+	}
+
+	t.Run("Merge with defaults", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithMimeTypes(mimeTypes, false))
+		require.Nil(t, err)
+		assert.Equal(t, len(mimeTypes)+len(buildDefaultTypers()), len(server.typers))
+		assert.Equal(t, "application/yaml", server.inferMimeType("config.yaml"))
+		assert.Equal(t, "application/yaml", server.inferMimeType("config.yml"))
+		assert.Equal(t, "application/x-gtar", server.inferMimeType("bundle.tar.gz"))
+		assert.Equal(t, "application/gzip", server.inferMimeType("data.gz"))
+		assert.Equal(t, utf8Type("text/x-custom-css"), server.inferMimeType("style.css"))
+		assert.Equal(t, utf8Type(mimeTypeJS), server.inferMimeType("app.js"))
+	})
+
+	t.Run("Replace defaults", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithMimeTypes(mimeTypes, true))
+		require.Nil(t, err)
+		assert.Equal(t, len(mimeTypes), len(server.typers))
+		assert.Equal(t, "application/yaml", server.inferMimeType("config.yaml"))
+		assert.Equal(t, mimeTypeUnknown, server.inferMimeType("app.js"))
+	})
+
+	t.Run("Deterministic order", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			server, err := NewAssetServerWithOptions("/assets/", testFiles, WithMimeTypes(mimeTypes, true))
+			require.Nil(t, err)
+			var patterns []string
+			for _, typer := range server.typers {
+				patterns = append(patterns, typer.expr.String())
+			}
+			assert.Equal(t, []string{`\.tar\.gz$`, `\.yaml$`, `\.css$`, `\.yml$`, `\.gz$`}, patterns)
+		}
+	})
+
+	t.Run("Respects MaxTypers", func(t *testing.T) {
+		limit := func(server *AssetServer) error {
+			server.MaxTypers = len(mimeTypes)
+			return nil
+		}
+		_, err := NewAssetServerWithOptions("/assets/", testFiles, limit, WithMimeTypes(mimeTypes, true))
+		assert.Nil(t, err)
+
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, limit, WithMimeTypes(mimeTypes, false))
+		assert.Nil(t, server)
+		assert.ErrorIs(t, err, ErrTooManyTypers)
+	})
+
+	t.Run("Empty extension is rejected", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithMimeTypes(map[string]string{".": "text/plain"}, false))
+		assert.Nil(t, server)
+		assert.Equal(t, ErrBadMimeMapping, err)
+	})
+
+	t.Run("Empty mime type is rejected", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithMimeTypes(map[string]string{"txt": ""}, false))
+		assert.Nil(t, server)
+		assert.Equal(t, ErrBadMimeMapping, err)
+	})
+}
+
+func TestFieldOptions(t *testing.T) {
+	t.Run("Several options together", func(t *testing.T) {
+		errFunc := func(w http.ResponseWriter, r *http.Request, err error) {
+			w.WriteHeader(http.StatusTeapot)
+		}
+		server, err := NewAssetServerWithOptions("/assets/", testFiles,
+			WithFSPrefix("prefix/"),
+			WithBrotliSuffix(".br"),
+			WithGzipLevel(gzip.BestSpeed),
+			WithErrFunc(errFunc),
+			WithHeaderFunc(DefaultHeaderFunc),
+			WithMimeType(regexp.MustCompile(`\.heic$`), "image/heic", false),
+		)
+		require.Nil(t, err)
+		assert.Equal(t, "prefix/", server.FSPrefix)
+		assert.Equal(t, ".br", server.BrotliSuffix)
+		assert.Equal(t, gzip.BestSpeed, server.GzipLevel)
+		assert.Equal(t, "image/heic", server.inferMimeType("photo.heic"))
+		require.NoError(t, server.Check())
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/script.js", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "prefixed js", w.Body.String())
+		assert.NotEmpty(t, w.Header().Get("Cache-Control"))
+
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/missing.js", nil))
+		assert.Equal(t, http.StatusTeapot, w.Code)
+	})
+
+	t.Run("Empty values disable", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles,
+			WithFSPrefix(""), WithBrotliSuffix(""), WithHeaderFunc(nil))
+		require.Nil(t, err)
+		assert.Empty(t, server.FSPrefix)
+		assert.Empty(t, server.BrotliSuffix)
+		assert.Nil(t, server.HeaderFunc)
+	})
+
+	tests := []struct {
+		name     string
+		option   Option
+		expected error
+	}{
+		{"Absolute FSPrefix", WithFSPrefix("/srv/"), ErrAbsoluteFSPrefix},
+		{"FSPrefix without trailing slash", WithFSPrefix("public"), ErrBadFSPrefix},
+		{"Brotli suffix without dot", WithBrotliSuffix("br"), ErrBadBrotliSuffix},
+		{"Nil ErrFunc", WithErrFunc(nil), ErrNilErrFunc},
+		{"Gzip level too low", WithGzipLevel(gzip.HuffmanOnly - 1), ErrBadGzipLevel},
+		{"Gzip level too high", WithGzipLevel(gzip.BestCompression + 1), ErrBadGzipLevel},
+		{"Nil pattern", WithMimeType(nil, "image/heic", false), ErrBadMimeMapping},
+		{"Empty mime type", WithMimeType(regexp.MustCompile(`\.heic$`), "", false), ErrBadMimeMapping},
+		{"Duplicate mime type", WithMimeType(regexp.MustCompile(`\.sass$`), mimeTypeCSS, false), ErrMimeTypeRefused},
+		{"Conflicting extension", WithMimeType(regexp.MustCompile(`\.css$`), "text/x-other", false), ErrMimeTypeRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewAssetServerWithOptions("/assets/", testFiles, tt.option)
+			assert.Nil(t, server)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
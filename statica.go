@@ -15,12 +15,24 @@
 package statica
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
+	"path"
 	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/maypok86/otter/v2"
 )
 
 // mimeTyper infers mime types from file names
@@ -32,9 +44,62 @@ type mimeTyper struct {
 // StaticaHeaderFunc is used to set headers on a response
 type StaticaHeaderFunc func(w http.ResponseWriter, data []byte)
 
+// StaticaAssetHeaderFunc sets headers on a response with access to the request and the
+// asset being served
+type StaticaAssetHeaderFunc func(w http.ResponseWriter, r *http.Request, asset AssetInfo)
+
+// AssetInfo describes the asset a response carries
+type AssetInfo struct {
+	// Path is the asset's path relative to the route
+	Path string
+	// Data is the response body, after any transforms and compression
+	Data    []byte
+	modTime time.Time
+}
+
+// ModTime returns the modification time of the files the asset was read from, the newest
+// of them for a bundle. It is the zero time when the filesystem doesn't report one or the
+// asset isn't read from files, e.g. bytes given to ServeBytes or a manifest.
+func (asset AssetInfo) ModTime() time.Time {
+	return asset.modTime
+}
+
 // StaticaErrFunc translates Go errors into HTTP responses
 type StaticaErrFunc func(w http.ResponseWriter, r *http.Request, err error)
 
+// MaintenanceConfig describes the page served while an AssetServer is in maintenance mode
+type MaintenanceConfig struct {
+	// File is the path of the maintenance page in the asset filesystem. FSPrefix is applied.
+	File string
+	// RetryAfter is sent in the Retry-After header when greater than zero,
+	// otherwise the server's RetryAfterSeconds is used
+	RetryAfter int
+	// Exempt matches requested paths which are served normally, e.g. health checks
+	Exempt *regexp.Regexp
+}
+
+// StaticaErrorLogFunc receives errors which cannot be reported to the client, such as a
+// failed write after the response status has been sent or the filesystem detail behind a
+// generic error response. r is nil for errors raised while configuring the server.
+type StaticaErrorLogFunc func(r *http.Request, err error)
+
+// StaticaRedirectFunc returns, with ok true, the Location and status code a request is
+// redirected with instead of being served
+type StaticaRedirectFunc func(r *http.Request) (location string, status int, ok bool)
+
+// DisabledVariantPolicy controls how requests naming a compressed variant, such as
+// app.css.br, are handled when that encoding is not enabled
+type DisabledVariantPolicy int
+
+const (
+	// DisabledVariantLiteral reads the requested path as an ordinary file
+	DisabledVariantLiteral DisabledVariantPolicy = iota
+	// DisabledVariantNotFound responds as if the file does not exist
+	DisabledVariantNotFound
+	// DisabledVariantOriginal serves the uncompressed original, e.g. app.css for app.css.br
+	DisabledVariantOriginal
+)
+
 // AssetServer serves static assets from a fs.ReadFileFS
 type AssetServer struct {
 	files        fs.ReadFileFS
@@ -44,8 +109,188 @@ type AssetServer struct {
 	ErrFunc      StaticaErrFunc
 	HeaderFunc   StaticaHeaderFunc
 	BrotliSuffix string
+	Maintenance  *MaintenanceConfig
+	// ZstdSuffix, when set, names precompressed zstd variants, mirroring BrotliSuffix.
+	// Clients accepting both encodings equally are served zstd in preference to Brotli.
+	ZstdSuffix string
+	// defaultMimeType replaces mimeTypeUnknown when no typer matches
+	defaultMimeType string
+	// CompressionUADenyList matches User-Agents that are always served uncompressed
+	// variants, regardless of what they advertise in Accept-Encoding
+	CompressionUADenyList []*regexp.Regexp
+	// ForceContentType, when set, is sent as the Content-Type of every asset and
+	// bypasses mime type inference
+	ForceContentType string
+	// SaveDataSuffix, when set, names a reduced variant inserted before the file
+	// extension (e.g. ".min" maps app.js to app.min.js) which is preferred for
+	// clients sending "Save-Data: on"
+	SaveDataSuffix string
+	// GzipLevel is the compress/gzip level used when compressing responses on the fly.
+	// Defaults to gzip.DefaultCompression.
+	GzipLevel int
+	// Transforms rewrite uncompressed asset contents, in order, before headers are set.
+	// Precompressed variants are never transformed.
+	Transforms []StaticaTransformFunc
+	// IndexFile is served for requests naming a directory (the route itself or a path
+	// ending in "/"), e.g. "index.html". When empty such requests are not found.
+	IndexFile string
+	// ErrorLogFunc, when set, is called with errors that cannot be reported to the client,
+	// including the detail behind every error response before it is handed to ErrFunc
+	ErrorLogFunc StaticaErrorLogFunc
+	// LogFunc, when set, is called once each request served by ServeHTTP or ServeFile
+	// has been answered, for access logging
+	LogFunc StaticaLogFunc
+	// Metrics, when set, is told when each request served by ServeHTTP or ServeFile
+	// starts and finishes
+	Metrics MetricsCollector
+	// RetryAfterSeconds is sent as Retry-After on 503 responses generated by the server,
+	// such as maintenance mode, when greater than zero
+	RetryAfterSeconds int
+	// LanguagePattern, when set, is matched against requested paths and its first capture
+	// group is sent as Content-Language, e.g. `\.([a-z]{2}(?:-[A-Z]{2})?)\.html$`
+	LanguagePattern *regexp.Regexp
+	// LanguageNegotiation, when set, serves language variants of requested files
+	// selected by the Accept-Language header
+	LanguageNegotiation *LanguageNegotiation
+	// BotUserAgents matches crawler User-Agents which are served prerendered snapshots
+	BotUserAgents []*regexp.Regexp
+	// PrerenderDir is the route-relative directory holding prerendered snapshots. A bot
+	// requesting app/page.html is served PrerenderDir/app/page.html when it exists.
+	PrerenderDir string
+	// MaxTypers, when greater than zero, caps the number of registered mime typers.
+	// Registrations beyond the cap are refused with ErrTooManyTypers, which catches
+	// RegisterMimeType calls stuck in a loop. RegisterMimeType reports it to ErrorLogFunc;
+	// SetMimeTypeForExtension and WithMimeTypes return it.
+	MaxTypers int
+	// DisabledVariants controls direct requests for ".br" paths while BrotliSuffix is
+	// empty, ".zst" paths while ZstdSuffix is empty, and ".gz" paths, whose encodings
+	// are not served. Defaults to
+	// DisabledVariantLiteral, which reads them as ordinary files.
+	DisabledVariants DisabledVariantPolicy
+	// DecodeDirectVariantRequests, when true, decompresses direct requests for Brotli
+	// variants (e.g. app.js.br) for clients which don't accept br, so such URLs work
+	// in a browser. Clients sending "Accept-Encoding: br" still receive the raw bytes.
+	DecodeDirectVariantRequests bool
+	// ETagFunc computes the ETag sent with each asset from the bytes actually served.
+	// Requests whose If-None-Match matches receive 304 Not Modified. Defaults to
+	// DefaultETagFunc; set to nil to disable ETags.
+	ETagFunc StaticaETagFunc
+	// SPAFallback, when set, names the route-relative file, typically "index.html", served
+	// with a 200 for missing paths so client-side routing works. Missing paths with an
+	// extension of a registered mime type, such as a stylesheet, still respond 404.
+	SPAFallback string
+	// NotFoundFile, when set, names the route-relative file served with a 404 instead of
+	// calling ErrFunc for missing assets. ErrFunc still handles every other error, and
+	// the missing file itself if NotFoundFile can't be read.
+	NotFoundFile string
+	// RedirectFunc, when set, is consulted before an asset is read, so moved assets can be
+	// redirected to their new paths. A zero status redirects with 301 Moved Permanently.
+	// Paths rejected as malformed, traversing, or hidden, and maintenance mode, come first.
+	RedirectFunc StaticaRedirectFunc
+	// BypassCacheParam, when set, names a query parameter, e.g. "nocache", which makes a
+	// request Invalidate its asset before reading it, so edits show up without waiting for
+	// the cache. Meant for development; leave it empty in production, where any client
+	// could otherwise force reads from the underlying filesystem.
+	BypassCacheParam string
+	// Middleware wraps every request to ServeHTTP, ServeFile, and BundleHandler, the first
+	// outermost, e.g. to add headers which depend on the request. SecurityHeaders is a
+	// built-in. Middleware sees the request before the route is stripped from its path.
+	Middleware []func(http.Handler) http.Handler
+	// StrictFilenames rejects, as not found, paths containing Windows reserved device
+	// names such as "con" or "nul.txt" and names ending in a dot or space, so the same
+	// filesystem behaves identically whatever OS serves it
+	StrictFilenames bool
+	// HideDotfiles rejects, as not found, paths with any element starting with a dot,
+	// so files such as .env or .git/config embedded by accident are never served
+	HideDotfiles bool
+	// FollowSymlinks serves files reached through symbolic links. By default they are
+	// refused with fs.ErrPermission, so a link in an on-disk root can't expose files
+	// outside it. Links in FSPrefix itself are followed. Only filesystems implementing
+	// fs.ReadLinkFS, such as os.DirFS or a CachingFS over one, can be checked; embed.FS
+	// has no symbolic links.
+	FollowSymlinks bool
+	// AllowedMethods lists the request methods served. Other methods receive 405 Method
+	// Not Allowed with an Allow header. Defaults to GET and HEAD; empty allows any method.
+	AllowedMethods []string
+	// PrefixStripped treats request paths as already relative to the route, for servers
+	// mounted with http.StripPrefix or a router's Mount, which remove the mount path
+	// themselves. A leading "/" left behind by the stripping is ignored.
+	PrefixStripped bool
+	// CORSOrigin, when set, is sent as Access-Control-Allow-Origin on every response, "*"
+	// for any origin, so pages elsewhere can load assets such as fonts. OPTIONS requests
+	// are then answered as CORS preflights with 204, whatever AllowedMethods lists.
+	CORSOrigin string
+	// EnableGzip compresses text, JSON, JavaScript, XML, and SVG responses with GzipLevel
+	// for clients accepting gzip when no Brotli variant was served
+	EnableGzip bool
+	// GzipMinSize is the smallest response, in bytes, compressed when EnableGzip is set.
+	// Defaults to DefaultGzipMinSize.
+	GzipMinSize int
+	// CacheNamespace, when set, scopes reads from filesystems implementing NamespacedFS,
+	// such as CachingFS, so several servers can share one cache without sharing entries
+	CacheNamespace string
+	// AssetHeaderFunc, when set, is called after HeaderFunc with the request and an
+	// AssetInfo describing the response, for headers which depend on more than the bytes,
+	// such as AgeBasedCacheControl
+	AssetHeaderFunc StaticaAssetHeaderFunc
+	// RangeTypes lists the media types, exact or with a "/*" subtype wildcard, for which
+	// Accept-Ranges is advertised and Range requests are honored. Other assets are always
+	// served whole. Defaults to "video/*" and "audio/*".
+	RangeTypes []string
+	// SniffContentType, when true, detects the type of uncompressed assets no typer
+	// matches from their first 512 bytes with http.DetectContentType, rather than
+	// serving them as application/octet-stream or the SetDefaultMimeType type
+	SniffContentType bool
+	// DefaultCharset is appended as "; charset=..." to inferred text types, such as
+	// text/css, application/json, and +xml types, which don't already name a charset.
+	// Binary types never get one. Defaults to "utf-8"; empty disables it.
+	DefaultCharset string
+	// TimingAllowOrigin, when set, is sent as Timing-Allow-Origin on asset responses so
+	// pages on other origins, "*" for any, can read detailed Resource Timing data for them
+	TimingAllowOrigin string
+	// StreamThreshold, when positive, is the size in bytes above which files are copied
+	// to the response from fs.File rather than read into memory, using http.ServeContent
+	// for Range and If-Modified-Since when the file is an io.ReadSeeker. Streamed files
+	// skip precompressed variants, transforms, gzip, and ETags, and HeaderFunc receives
+	// nil data. Requests handled by LanguageNegotiation, and clients refusing identity,
+	// are never streamed.
+	StreamThreshold int
+	// UseServeContent defers to http.ServeContent, with the file's modification time, for
+	// every file whose fs.File is an io.ReadSeeker, so Range, If-Range, and
+	// If-Modified-Since are handled by the standard library. Like streamed files, these
+	// skip precompressed Brotli and zstd variants, so it is incompatible with precompression.
+	// Other files are read and served normally.
+	UseServeContent bool
+	// LastModified sends Last-Modified from the served file's modification time, when the
+	// filesystem reports one, and answers satisfied If-Modified-Since requests with 304.
+	// It costs a Stat per request, which CachingFS caches alongside the file. Defaults
+	// to true.
+	LastModified bool
+	// CacheETags remembers the headers of every response sent with an ETag, so a
+	// conditional GET naming one of those tags is answered 304 without reading the asset.
+	// Remembered tags outlive changes to the files; call Invalidate or InvalidateAll,
+	// rather than CachingFS.Invalidate, when files change so stale tags are dropped.
+	// Only the ETag, Cache-Control, Vary, Content-Location, Expires, and Last-Modified
+	// headers are replayed, as first sent; AssetHeaderFunc and HeaderFunc don't run, while
+	// Middleware still sets per-request headers. Up to 10,000 tags are remembered, the
+	// least used evicted first.
+	CacheETags bool
+	// etags holds the headers remembered for CacheETags, keyed by etagKey, and is created
+	// on first use by rememberedETags
+	etags     *otter.Cache[string, http.Header]
+	etagsOnce sync.Once
+	// integrity memoizes Integrity results by route-relative path
+	integrity sync.Map
+	// dispositions are the RegisterDisposition rules, in match order
+	dispositions []dispositionRule
+	// cacheControls are the RegisterCacheControl rules, in match order
+	cacheControls []cacheControlRule
 }
 
+// DefaultGzipMinSize is the default GzipMinSize. Smaller responses rarely shrink enough to
+// be worth the CPU.
+const DefaultGzipMinSize = 1024
+
 // Default mime types
 const (
 	mimeTypeCSS     = "text/css"
@@ -57,9 +302,19 @@ const (
 	mimeTypeWOFF    = "font/woff"
 	mimeTypeJPG     = "image/jpeg"
 	mimeTypeText    = "text/plain"
+	mimeTypeWASM    = "application/wasm"
+	mimeTypeSVG     = "image/svg+xml"
+	mimeTypeWebP    = "image/webp"
+	mimeTypeAVIF    = "image/avif"
+	mimeTypeMP4     = "video/mp4"
+	mimeTypeICO     = "image/x-icon"
 	mimeTypeUnknown = "application/octet-stream"
 )
 
+// mimeTypeWebManifest types web app manifests, which browsers won't install from when
+// they're served as plain JSON
+const mimeTypeWebManifest = "application/manifest+json"
+
 var (
 	cssRegex   = regexp.MustCompile(`\.css$`)
 	jsRegex    = regexp.MustCompile(`\.js$`)
@@ -71,33 +326,114 @@ var (
 	jpegRegex  = regexp.MustCompile(`\.jpeg$`)
 	jpgRegex   = regexp.MustCompile(`\.jpg$`)
 	txtRegex   = regexp.MustCompile(`\.txt$`)
+	wasmRegex  = regexp.MustCompile(`\.wasm$`)
+	svgRegex   = regexp.MustCompile(`\.svg$`)
+	webpRegex  = regexp.MustCompile(`\.webp$`)
+	avifRegex  = regexp.MustCompile(`\.avif$`)
+	mp4Regex   = regexp.MustCompile(`\.mp4$`)
+	icoRegex   = regexp.MustCompile(`\.ico$`)
+	// Source maps, e.g. app.js.map, which jsRegex doesn't match
+	mapRegex = regexp.MustCompile(`\.map$`)
+	// manifest.json is conventionally the web app manifest, so this must precede jsonRegex
+	webManifestRegex = regexp.MustCompile(`(^|/)manifest\.json$|\.webmanifest$`)
 )
 
 var ErrEmptyRoute = errors.New("assets route is empty")
 var ErrNilFS = errors.New("asset filesystem is nil")
+var ErrBadRoute = errors.New("assets route must start and end with '/'")
 var ErrAbsoluteFSPrefix = errors.New("filesystem prefix is an absolute path")
 var ErrBadFSPrefix = errors.New("filesystem prefix does not end with '/'")
 var ErrBadBrotliSuffix = errors.New("brotli suffix does not start with '.'")
+var ErrBadZstdSuffix = errors.New("zstd suffix does not start with '.'")
+var ErrNotAcceptable = errors.New("no acceptable content encoding available")
+var ErrBadGzipLevel = errors.New("gzip level is out of range")
+var ErrExtensionConflict = errors.New("extension is already mapped to a different mime type")
+var ErrTooManyTypers = errors.New("mime typer limit reached")
+var ErrMethodNotAllowed = errors.New("request method is not allowed")
+var ErrBadRequestPath = errors.New("request path is malformed")
 
 const brotliEncoding = "br"
 
-// DefaultErrFunc translates errors into 404, 403, or 500 status codes depending on the error
-func DefaultErrFunc(w http.ResponseWriter, r *http.Request, err error) {
+// errorStatus maps an error to the HTTP status code reported to clients
+func errorStatus(err error) int {
 	if errors.Is(err, fs.ErrNotExist) {
-		w.WriteHeader(http.StatusNotFound)
+		return http.StatusNotFound
 	} else if errors.Is(err, fs.ErrPermission) {
-		w.WriteHeader(http.StatusForbidden)
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
+		return http.StatusForbidden
+	} else if errors.Is(err, ErrNotAcceptable) {
+		return http.StatusNotAcceptable
+	} else if errors.Is(err, ErrBadBundle) || errors.Is(err, ErrBadRequestPath) {
+		return http.StatusBadRequest
+	} else if errors.Is(err, ErrMethodNotAllowed) {
+		return http.StatusMethodNotAllowed
 	}
-	w.Header().Add("Content-Type", "text/plain")
-	w.Write([]byte(err.Error()))
+	return http.StatusInternalServerError
+}
+
+// DefaultErrFunc translates errors into 400, 404, 403, 405, 406, or 500 status codes
+// depending on the error. The body is the generic status text, e.g. "Not Found", so file
+// paths and filesystem details stay out of responses; set ErrorLogFunc to record the
+// underlying error.
+func DefaultErrFunc(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(status)
+	w.Write([]byte(http.StatusText(status)))
+}
+
+// ContentNegotiatedErrFunc uses the same status codes as DefaultErrFunc but writes the body
+// as JSON, HTML, or plain text depending on the request's Accept header
+func ContentNegotiatedErrFunc(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	message := strings.ToLower(http.StatusText(status))
+	var body []byte
+	switch negotiateErrorType(r.Header.Get("Accept")) {
+	case mimeTypeJSON:
+		w.Header().Set("Content-Type", mimeTypeJSON)
+		body, _ = json.Marshal(map[string]string{"error": message})
+	case mimeTypeHTML:
+		w.Header().Set("Content-Type", mimeTypeHTML+"; charset=utf-8")
+		body = []byte(fmt.Sprintf("<!DOCTYPE html>\n<html><head><title>%d %s</title></head><body><h1>%s</h1></body></html>\n",
+			status, http.StatusText(status), http.StatusText(status)))
+	default:
+		w.Header().Set("Content-Type", mimeTypeText+"; charset=utf-8")
+		body = []byte(message)
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// negotiateErrorType picks the error body format best matching an Accept header.
+// Ties and missing headers prefer plain text.
+func negotiateErrorType(accept string) string {
+	if accept == "" {
+		return mimeTypeText
+	}
+	ranges := parseQualityValues(accept)
+	quality := func(mimeType string) float64 {
+		if q, ok := ranges[mimeType]; ok {
+			return q
+		}
+		major, _, _ := strings.Cut(mimeType, "/")
+		if q, ok := ranges[major+"/*"]; ok {
+			return q
+		}
+		return ranges["*/*"]
+	}
+	best := mimeTypeText
+	bestQuality := quality(mimeTypeText)
+	for _, candidate := range []string{mimeTypeHTML, mimeTypeJSON} {
+		if q := quality(candidate); q > bestQuality {
+			best = candidate
+			bestQuality = q
+		}
+	}
+	return best
 }
 
 // DefaultHeaderFunc sets Cache-Control header such clients will cache assets for 7 days
 func DefaultHeaderFunc(w http.ResponseWriter, data []byte) {
-	const cacheHeader = "private, max-age=604800"
-	w.Header().Add("Cache-Control", cacheHeader)
+	w.Header().Add("Cache-Control", DefaultCacheControl)
 }
 
 func buildDefaultTypers() []mimeTyper {
@@ -106,6 +442,7 @@ func buildDefaultTypers() []mimeTyper {
 		{cssRegex, mimeTypeCSS},
 		{jsRegex, mimeTypeJS},
 		{htmlRegex, mimeTypeHTML},
+		{webManifestRegex, mimeTypeWebManifest},
 		{jsonRegex, mimeTypeJSON},
 		{pngRegex, mimeTypePNG},
 		{woff2Regex, mimeTypeWOFF2},
@@ -113,30 +450,45 @@ func buildDefaultTypers() []mimeTyper {
 		{jpegRegex, mimeTypeJPG},
 		{jpgRegex, mimeTypeJPG},
 		{txtRegex, mimeTypeText},
+		{wasmRegex, mimeTypeWASM},
+		{svgRegex, mimeTypeSVG},
+		{webpRegex, mimeTypeWebP},
+		{avifRegex, mimeTypeAVIF},
+		{mp4Regex, mimeTypeMP4},
+		{icoRegex, mimeTypeICO},
+		{mapRegex, mimeTypeJSON},
 	}
 	return typers
 }
 
-// NewAssetServer creates a new AssetServer instance
-func NewAssetServer(route string, files fs.ReadFileFS) (*AssetServer, error) {
-	if route == "" {
-		return nil, ErrEmptyRoute
+// NewAssetServer creates a new AssetServer instance. Filesystems which only implement
+// Open, such as a *zip.Reader, are adapted with AdaptFS.
+func NewAssetServer(route string, files fs.FS) (*AssetServer, error) {
+	if err := checkRoute(route); err != nil {
+		return nil, err
 	}
 	if files == nil {
 		return nil, ErrNilFS
 	}
 	return &AssetServer{
-		route:   route,
-		files:   files,
-		typers:  buildDefaultTypers(),
-		ErrFunc: DefaultErrFunc,
+		route:          route,
+		files:          AdaptFS(files),
+		typers:         buildDefaultTypers(),
+		ErrFunc:        DefaultErrFunc,
+		GzipLevel:      gzip.DefaultCompression,
+		ETagFunc:       DefaultETagFunc,
+		LastModified:   true,
+		GzipMinSize:    DefaultGzipMinSize,
+		AllowedMethods: []string{http.MethodGet, http.MethodHead},
+		RangeTypes:     []string{"video/*", "audio/*"},
+		DefaultCharset: "utf-8",
 	}, nil
 }
 
 // Check verifies the AssetServer instance is properly configured
 func (server *AssetServer) Check() error {
-	if server.route == "" {
-		return ErrEmptyRoute
+	if err := checkRoute(server.route); err != nil {
+		return err
 	}
 	if server.files == nil {
 		return ErrNilFS
@@ -146,6 +498,14 @@ func (server *AssetServer) Check() error {
 			return ErrBadBrotliSuffix
 		}
 	}
+	if server.ZstdSuffix != "" {
+		if !strings.HasPrefix(server.ZstdSuffix, ".") {
+			return ErrBadZstdSuffix
+		}
+	}
+	if server.GzipLevel < gzip.HuffmanOnly || server.GzipLevel > gzip.BestCompression {
+		return ErrBadGzipLevel
+	}
 	if server.FSPrefix != "" {
 		if strings.HasPrefix(server.FSPrefix, "/") {
 			return ErrAbsoluteFSPrefix
@@ -157,101 +517,458 @@ func (server *AssetServer) Check() error {
 	return nil
 }
 
+// checkRoute rejects routes ServeHTTP can't strip cleanly. The route is trimmed from the
+// request path as a plain prefix, so "/assets" would leave "/app.js" and also match
+// "/assetsfoo/app.js"
+func checkRoute(route string) error {
+	if route == "" {
+		return ErrEmptyRoute
+	}
+	if !strings.HasPrefix(route, "/") || !strings.HasSuffix(route, "/") {
+		return ErrBadRoute
+	}
+	return nil
+}
+
+// inferMimeType matches typers against the full route-relative path, not just its extension
 func (server *AssetServer) inferMimeType(filePath string) string {
-	if server.BrotliSuffix != "" && strings.HasSuffix(filePath, server.BrotliSuffix) {
-		filePath = strings.TrimSuffix(filePath, server.BrotliSuffix)
+	return server.withCharset(server.matchMimeType(server.variantBase(filePath)))
+}
+
+// withCharset appends DefaultCharset to text mime types without a charset parameter
+func (server *AssetServer) withCharset(mimeType string) string {
+	if server.DefaultCharset == "" || !textMimeType(mimeType) || strings.Contains(strings.ToLower(mimeType), "charset=") {
+		return mimeType
 	}
-	mimeType := mimeTypeUnknown
+	return mimeType + "; charset=" + server.DefaultCharset
+}
+
+// variantBase strips a precompressed variant suffix so the original's type is inferred
+func (server *AssetServer) variantBase(filePath string) string {
+	if encoding := server.directEncoding(filePath); encoding != "" {
+		return strings.TrimSuffix(filePath, server.variantSuffix(encoding))
+	}
+	return filePath
+}
+
+// detectMimeType infers the type of data served for requestedPath, sniffing uncompressed
+// data when SniffContentType is set and no typer matches
+func (server *AssetServer) detectMimeType(requestedPath string, data []byte, encoding string) string {
+	if server.SniffContentType && encoding == "" {
+		if _, matched := server.typerMimeType(server.variantBase(requestedPath)); !matched {
+			if sniffed := http.DetectContentType(data); sniffed != mimeTypeUnknown {
+				return sniffed
+			}
+		}
+	}
+	return server.inferMimeType(requestedPath)
+}
+
+// typerMimeType returns the mime type of the first typer matching filePath, if any
+func (server *AssetServer) typerMimeType(filePath string) (string, bool) {
 	for _, typer := range server.typers {
 		if typer.expr.MatchString(filePath) {
-			mimeType = typer.mimeType
-			break
+			return typer.mimeType, true
 		}
 	}
-	return mimeType
+	return "", false
 }
 
-func (server *AssetServer) readFile(filePath string) ([]byte, bool, error) {
-	var isBrotli = false
-	var data []byte
-	var err error
+// matchMimeType returns the mime type of the first typer matching filePath
+func (server *AssetServer) matchMimeType(filePath string) string {
+	if mimeType, matched := server.typerMimeType(filePath); matched {
+		return mimeType
+	}
+	if server.defaultMimeType != "" {
+		return server.defaultMimeType
+	}
+	return mimeTypeUnknown
+}
 
-	// Apply FSPrefix if configured
+// SetDefaultMimeType sets the mime type used when no registered typer matches, in place
+// of application/octet-stream. It always runs after every typer, unlike a catch-all
+// pattern. An empty mimeType restores application/octet-stream.
+// This method is not safe for concurrent use with ServeHTTP. Configure the server
+// before serving requests
+func (server *AssetServer) SetDefaultMimeType(mimeType string) {
+	server.defaultMimeType = mimeType
+}
+
+// fsPath maps a requested path to its location in the asset filesystem
+func (server *AssetServer) fsPath(filePath string) string {
 	if server.FSPrefix != "" {
-		filePath = fmt.Sprintf("%s%s", server.FSPrefix, filePath)
+		return fmt.Sprintf("%s%s", server.FSPrefix, filePath)
+	}
+	return filePath
+}
+
+// modTime returns the modification time of the file at the route relative filePath, or
+// the zero time when it can't be determined
+func (server *AssetServer) modTime(filePath string) time.Time {
+	info, err := fs.Stat(server.files, server.fsPath(filePath))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// assetModTime returns the newest modification time of the route relative filePaths an
+// asset is read from, for Last-Modified and AssetInfo. Nothing is stat'ed when neither
+// needs it.
+func (server *AssetServer) assetModTime(filePaths ...string) time.Time {
+	var newest time.Time
+	if !server.LastModified && server.AssetHeaderFunc == nil {
+		return newest
+	}
+	for _, filePath := range filePaths {
+		if modTime := server.modTime(filePath); modTime.After(newest) {
+			newest = modTime
+		}
 	}
+	return newest
+}
 
-	brotliRequested := strings.HasSuffix(filePath, server.BrotliSuffix)
-	if server.BrotliSuffix != "" && !brotliRequested {
-		brotliPath := fmt.Sprintf("%s%s", filePath, server.BrotliSuffix)
-		data, err = server.files.ReadFile(brotliPath)
-		if err == nil {
-			isBrotli = true
+// compressionAllowed reports whether compressed variants may be offered to the client
+func (server *AssetServer) compressionAllowed(r *http.Request) bool {
+	if len(server.CompressionUADenyList) == 0 {
+		return true
+	}
+	userAgent := r.UserAgent()
+	for _, expr := range server.CompressionUADenyList {
+		if expr.MatchString(userAgent) {
+			return false
 		}
 	}
-	if !isBrotli {
-		data, err = server.files.ReadFile(filePath)
-		if err == nil && brotliRequested && server.BrotliSuffix != "" {
-			isBrotli = true
+	return true
+}
+
+// isBot reports whether the request's User-Agent matches BotUserAgents
+func (server *AssetServer) isBot(r *http.Request) bool {
+	userAgent := r.UserAgent()
+	for _, expr := range server.BotUserAgents {
+		if expr.MatchString(userAgent) {
+			return true
 		}
 	}
-	return data, isBrotli, err
+	return false
+}
+
+// saveDataRequested reports whether the client asked for reduced data usage
+func saveDataRequested(r *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get("Save-Data")), "on")
+}
+
+// saveDataPath maps a requested path to its reduced variant
+func (server *AssetServer) saveDataPath(filePath string) string {
+	ext := path.Ext(filePath)
+	return fmt.Sprintf("%s%s%s", strings.TrimSuffix(filePath, ext), server.SaveDataSuffix, ext)
+}
+
+// readFS reads filePath, already mapped by fsPath, from the asset filesystem. Links are
+// looked for only once the read succeeds, so probes for absent variants cost no Lstat,
+// and a CachingFS answers the lookups of warm paths from its cache.
+func (server *AssetServer) readFS(ctx context.Context, filePath string) ([]byte, error) {
+	data, err := server.readFiles(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !server.FollowSymlinks && symlinked(server.files, server.FSPrefix, filePath) {
+		return nil, &fs.PathError{Op: "read", Path: filePath, Err: fs.ErrPermission}
+	}
+	return data, nil
+}
+
+// readFiles reads filePath through the most capable interface the filesystem offers
+func (server *AssetServer) readFiles(ctx context.Context, filePath string) ([]byte, error) {
+	if server.CacheNamespace != "" {
+		if nsFiles, ok := server.files.(ContextNamespacedFS); ok {
+			return nsFiles.ReadFileNSCtx(ctx, server.CacheNamespace, filePath)
+		}
+		if nsFiles, ok := server.files.(NamespacedFS); ok {
+			return nsFiles.ReadFileNS(server.CacheNamespace, filePath)
+		}
+	}
+	if ctxFiles, ok := server.files.(ContextFS); ok {
+		return ctxFiles.ReadFileCtx(ctx, filePath)
+	}
+	return server.files.ReadFile(filePath)
+}
+
+// gzipAllowed reports whether an uncompressed response should be gzipped on the fly
+func (server *AssetServer) gzipAllowed(r *http.Request, mimeType string, data []byte) bool {
+	return server.EnableGzip && len(data) >= server.GzipMinSize && compressibleMimeType(mimeType) &&
+		server.compressionAllowed(r) && acceptsEncoding(r.Header.Get("Accept-Encoding"), gzipEncoding)
+}
+
+// readAsset selects and reads the representation of requestedPath best suited to the request.
+// Returns the data and its content coding, which is empty for uncompressed data.
+func (server *AssetServer) readAsset(r *http.Request, requestedPath string) ([]byte, string, error) {
+	if server.SaveDataSuffix != "" && saveDataRequested(r) {
+		data, encoding, err := server.readNegotiated(r, server.saveDataPath(requestedPath))
+		if !errors.Is(err, fs.ErrNotExist) {
+			return data, encoding, err
+		}
+	}
+	return server.readNegotiated(r, requestedPath)
+}
+
+// readNegotiated reads filePath, preferring the precompressed variants the client ranks
+// above gzip and identity in Accept-Encoding, highest quality value first. Otherwise the
+// uncompressed file is read, to be gzipped or sent as is, falling back to a variant when
+// that is the only copy and the client hasn't refused its encoding.
+func (server *AssetServer) readNegotiated(r *http.Request, filePath string) ([]byte, string, error) {
+	if !server.compressionAllowed(r) {
+		return server.readFile(r.Context(), filePath, nil)
+	}
+	header := r.Header.Get("Accept-Encoding")
+	candidates := server.variantEncodings()
+	if server.EnableGzip && compressibleMimeType(server.inferMimeType(filePath)) {
+		candidates = append(candidates, gzipEncoding)
+	}
+	var accepted []string
+	for _, encoding := range preferredEncodings(header, append(candidates, identityEncoding)) {
+		if encoding == gzipEncoding || encoding == identityEncoding {
+			// variants ranked lower lose to the uncompressed file
+			break
+		}
+		accepted = append(accepted, encoding)
+	}
+	var fallback []string
+	for _, encoding := range server.variantEncodings() {
+		if !slices.Contains(accepted, encoding) && !encodingRefused(header, encoding) {
+			fallback = append(fallback, encoding)
+		}
+	}
+	data, encoding, err := server.readFile(r.Context(), filePath, accepted)
+	if !errors.Is(err, fs.ErrNotExist) || len(fallback) == 0 {
+		return data, encoding, err
+	}
+	if data, encoding, variantErr := server.readVariant(r.Context(), server.fsPath(filePath), fallback); variantErr == nil {
+		return data, encoding, nil
+	}
+	return data, encoding, err
+}
+
+// variantEncodings lists the content codings with precompressed variants enabled, in
+// order of preference
+func (server *AssetServer) variantEncodings() []string {
+	var encodings []string
+	if server.ZstdSuffix != "" {
+		encodings = append(encodings, zstdEncoding)
+	}
+	if server.BrotliSuffix != "" {
+		encodings = append(encodings, brotliEncoding)
+	}
+	return encodings
+}
+
+// negotiatesEncoding reports whether Accept-Encoding can change what is served, so
+// caches must keep responses apart by it
+func (server *AssetServer) negotiatesEncoding() bool {
+	return server.EnableGzip || len(server.variantEncodings()) > 0
+}
+
+// variantSuffix returns the configured file suffix of encoding's precompressed variants
+func (server *AssetServer) variantSuffix(encoding string) string {
+	switch encoding {
+	case zstdEncoding:
+		return server.ZstdSuffix
+	case brotliEncoding:
+		return server.BrotliSuffix
+	}
+	return ""
+}
+
+// directEncoding returns the content coding of filePath when it names a precompressed
+// variant directly, e.g. app.js.br
+func (server *AssetServer) directEncoding(filePath string) string {
+	for _, encoding := range server.variantEncodings() {
+		if strings.HasSuffix(filePath, server.variantSuffix(encoding)) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// readFile reads an asset, preferring the precompressed variants for encodings, in order.
+// Returns the data and its content coding, which is empty for uncompressed data.
+func (server *AssetServer) readFile(ctx context.Context, filePath string, encodings []string) ([]byte, string, error) {
+	filePath = server.fsPath(filePath)
+	if data, encoding, err := server.readVariant(ctx, filePath, encodings); err == nil {
+		return data, encoding, nil
+	}
+	data, err := server.readFS(ctx, filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, server.directEncoding(filePath), nil
+}
+
+// readVariant reads the first existing precompressed variant of filePath, already mapped
+// by fsPath, for encodings
+func (server *AssetServer) readVariant(ctx context.Context, filePath string, encodings []string) ([]byte, string, error) {
+	if server.directEncoding(filePath) == "" {
+		for _, encoding := range encodings {
+			data, err := server.readFS(ctx, filePath+server.variantSuffix(encoding))
+			if err == nil {
+				return data, encoding, nil
+			}
+		}
+	}
+	return nil, "", fs.ErrNotExist
+}
+
+// simpleExtension returns the extension matched by patterns of the form `\.ext$`
+func simpleExtension(expr *regexp.Regexp) (string, bool) {
+	re, err := syntax.Parse(expr.String(), syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	re = re.Simplify()
+	if re.Op != syntax.OpConcat || len(re.Sub) != 2 {
+		return "", false
+	}
+	literal, end := re.Sub[0], re.Sub[1]
+	if literal.Op != syntax.OpLiteral || literal.Flags&syntax.FoldCase != 0 || end.Op != syntax.OpEndText {
+		return "", false
+	}
+	ext := string(literal.Rune)
+	if len(ext) < 2 || ext[0] != '.' {
+		return "", false
+	}
+	return ext[1:], true
+}
+
+// extensionOwner returns the mime type the current typers infer for files with extension ext
+func (server *AssetServer) extensionOwner(ext string) (string, bool) {
+	probe := "file." + strings.TrimPrefix(ext, ".")
+	for _, typer := range server.typers {
+		if typer.expr.MatchString(probe) {
+			return typer.mimeType, true
+		}
+	}
+	return "", false
 }
 
 // RegisterMimeType adds a new mime type to a asset server instance. Returns true on success
-// and false if a duplicate mime type is detected. Set priority to true to make the mime type
-// check happen before the default built-in detectors.
+// and false if a duplicate mime type is detected or MaxTypers has been reached. Set
+// priority to true to make the mime type check happen before the default built-in
+// detectors.
+// Without priority, simple `\.ext$` patterns whose extension is already claimed by another
+// mime type are also refused, since the earlier typer would always win.
+// expr is matched against the full requested path relative to the route, without FSPrefix
+// or a leading slash, so patterns may be anchored to directories, e.g. `^js/.*\.map$`.
 // This method is not safe for concurrent use with other configuration
 // methods or with ServeHTTP. Configure the server before serving requests
 func (server *AssetServer) RegisterMimeType(expr *regexp.Regexp, mimeType string, priority bool) bool {
-	found := false
-	for _, typer := range server.typers {
-		if typer.mimeType == mimeType {
-			found = true
-			break
-		}
+	return server.RegisterMimeTypeExprs([]*regexp.Regexp{expr}, mimeType, priority)
+}
+
+// RegisterMimeTypeExprs adds a new mime type matched by any of exprs, e.g. `\.yml$` and
+// `\.yaml$` for application/yaml. The patterns are added together, in order, at the end of
+// the typers or, with priority, before all of them, so first-match-wins ordering is kept.
+// It succeeds or fails as a whole under the same rules as RegisterMimeType, counting each
+// pattern towards MaxTypers, and returns false when exprs is empty or contains nil.
+// This method is not safe for concurrent use with other configuration
+// methods or with ServeHTTP. Configure the server before serving requests
+func (server *AssetServer) RegisterMimeTypeExprs(exprs []*regexp.Regexp, mimeType string, priority bool) bool {
+	if len(exprs) == 0 || slices.Contains(exprs, nil) || server.IsMimeTypeRegistered(mimeType) {
+		return false
 	}
-	if found {
+	if server.exceedsMaxTypers(len(server.typers) + len(exprs)) {
+		if server.ErrorLogFunc != nil {
+			server.ErrorLogFunc(nil, fmt.Errorf("registering %s: %w", mimeType, ErrTooManyTypers))
+		}
 		return false
 	}
-	if priority {
-		server.typers = append([]mimeTyper{
-			{
-				expr:     expr,
-				mimeType: mimeType},
-		}, server.typers...)
-	} else {
-		server.typers = append(server.typers, mimeTyper{
+	if !priority {
+		for _, expr := range exprs {
+			if ext, ok := simpleExtension(expr); ok {
+				if owner, claimed := server.extensionOwner(ext); claimed && owner != mimeType {
+					return false
+				}
+			}
+		}
+	}
+	added := make([]mimeTyper, 0, len(exprs))
+	for _, expr := range exprs {
+		added = append(added, mimeTyper{
 			expr:     expr,
 			mimeType: mimeType,
 		})
 	}
+	if priority {
+		server.typers = append(added, server.typers...)
+	} else {
+		server.typers = append(server.typers, added...)
+	}
 	return true
 }
 
-// RemoveMimeType removes a typer from the asset server instance. Returns true on success
+// exceedsMaxTypers reports whether count typers would be more than MaxTypers allows
+func (server *AssetServer) exceedsMaxTypers(count int) bool {
+	return server.MaxTypers > 0 && count > server.MaxTypers
+}
+
+// SetMimeTypeForExtension maps files ending in ext (with or without a leading dot) to mimeType.
+// Returns ErrExtensionConflict if an existing typer already infers a different mime type for
+// that extension, or ErrTooManyTypers when MaxTypers has been reached. Registering an
+// extension which already maps to mimeType is a no-op.
+// This method is not safe for concurrent use with other configuration
+// methods or with ServeHTTP. Configure the server before serving requests
+func (server *AssetServer) SetMimeTypeForExtension(ext string, mimeType string) error {
+	if strings.TrimPrefix(ext, ".") == "" || mimeType == "" {
+		return ErrBadMimeMapping
+	}
+	if owner, claimed := server.extensionOwner(ext); claimed {
+		if owner != mimeType {
+			return ErrExtensionConflict
+		}
+		return nil
+	}
+	if server.exceedsMaxTypers(len(server.typers) + 1) {
+		return fmt.Errorf("registering %s: %w", mimeType, ErrTooManyTypers)
+	}
+	server.typers = append(server.typers, mimeTyper{
+		expr:     extensionRegex(ext),
+		mimeType: mimeType,
+	})
+	return nil
+}
+
+// RemoveMimeType removes the typers for mimeType, including every pattern added by
+// RegisterMimeTypeExprs, from the asset server instance. Returns true on success
 // and false if the mime type wasn't registered.
 func (server *AssetServer) RemoveMimeType(mimeType string) bool {
-	found := false
-	var target int
-	for i, typer := range server.typers {
-		if typer.mimeType == mimeType {
-			found = true
-			target = i
-			break
-		}
+	if !server.IsMimeTypeRegistered(mimeType) {
+		return false
 	}
-	if found {
-		if target == len(server.typers)-1 {
-			server.typers = server.typers[:target]
-		} else if target == 0 {
-			server.typers = server.typers[1:]
-		} else {
-			server.typers = append(server.typers[:target], server.typers[target+1:]...)
+	// Build a fresh slice so removal never writes into a backing array still
+	// referenced elsewhere
+	typers := make([]mimeTyper, 0, len(server.typers)-1)
+	for _, typer := range server.typers {
+		if typer.mimeType != mimeType {
+			typers = append(typers, typer)
 		}
-		return true
 	}
-	return false
+	server.typers = typers
+	return true
+}
+
+// MimeTypeMapping describes one registered mime typer
+type MimeTypeMapping struct {
+	Expr     *regexp.Regexp
+	MimeType string
+}
+
+// ListMimeTypes returns the registered typers in match order. The result is a copy, so
+// later registrations and removals never change a previously returned list.
+func (server *AssetServer) ListMimeTypes() []MimeTypeMapping {
+	mappings := make([]MimeTypeMapping, len(server.typers))
+	for i, typer := range server.typers {
+		mappings[i] = MimeTypeMapping{Expr: typer.expr, MimeType: typer.mimeType}
+	}
+	return mappings
 }
 
 // IsMimeTypeRegistered checks to see if a specific mime type has been set up for detection
@@ -265,23 +982,426 @@ func (server *AssetServer) IsMimeTypeRegistered(mimeType string) bool {
 	return false
 }
 
-// ServeHTTP serves requests for configured assets
+// writeUnavailableHeader sends a 503 status with a Retry-After hint when retryAfter is positive
+func (server *AssetServer) writeUnavailableHeader(w http.ResponseWriter, retryAfter int) {
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// serveMaintenance responds with the configured maintenance page and a 503 status
+func (server *AssetServer) serveMaintenance(w http.ResponseWriter, r *http.Request) {
+	maintenance := server.Maintenance
+	data, err := server.readFS(r.Context(), server.fsPath(maintenance.File))
+	if err != nil {
+		server.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Add("Content-Type", server.inferMimeType(maintenance.File))
+	retryAfter := maintenance.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = server.RetryAfterSeconds
+	}
+	server.writeUnavailableHeader(w, retryAfter)
+	server.write(w, r, maintenance.File, data)
+}
+
+// serveNotFound writes NotFoundFile with a 404, reporting false without writing anything
+// when it can't be read
+func (server *AssetServer) serveNotFound(w http.ResponseWriter, r *http.Request) bool {
+	data, err := server.readFS(r.Context(), server.fsPath(server.NotFoundFile))
+	if err != nil {
+		return false
+	}
+	w.Header().Set("Content-Type", server.inferMimeType(server.NotFoundFile))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusNotFound)
+	server.write(w, r, server.NotFoundFile, data)
+	return true
+}
+
+// RegisteredExtensions lists what the server's typers match, in match order. Simple
+// `\.ext$` patterns are reported as their extension with a leading dot, e.g. ".css";
+// other patterns are reported as the pattern string. Duplicates are omitted.
+func (server *AssetServer) RegisteredExtensions() []string {
+	seen := make(map[string]bool, len(server.typers))
+	extensions := make([]string, 0, len(server.typers))
+	for _, typer := range server.typers {
+		entry := typer.expr.String()
+		if ext, ok := simpleExtension(typer.expr); ok {
+			entry = "." + ext
+		}
+		if !seen[entry] {
+			seen[entry] = true
+			extensions = append(extensions, entry)
+		}
+	}
+	return extensions
+}
+
+// ServeHTTP serves requests for configured assets. Query strings, such as cache-busting
+// version parameters, never affect which file is read or the keys used by CachingFS.
 func (server *AssetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	requestedPath := strings.TrimPrefix(r.URL.Path, server.route)
-	data, isBrotli, err := server.readFile(requestedPath)
+	if len(server.Middleware) > 0 {
+		server.applyMiddleware(http.HandlerFunc(server.serveRoute)).ServeHTTP(w, r)
+		return
+	}
+	server.serveRoute(w, r)
+}
+
+// serveRoute serves the asset at the request path relative to the route
+func (server *AssetServer) serveRoute(w http.ResponseWriter, r *http.Request) {
+	server.serve(w, r, server.routePath(r.URL.Path))
+}
+
+// routePath returns the path of a request URL path relative to the route
+func (server *AssetServer) routePath(urlPath string) string {
+	if server.PrefixStripped {
+		return stripQuery(strings.TrimPrefix(urlPath, "/"))
+	}
+	return stripQuery(strings.TrimPrefix(urlPath, server.route))
+}
+
+// stripQuery guards against malformed paths which still carry a query or fragment,
+// e.g. from an encoded "%3F", by truncating at the first '?' or '#'
+func stripQuery(requestedPath string) string {
+	if i := strings.IndexAny(requestedPath, "?#"); i >= 0 {
+		return requestedPath[:i]
+	}
+	return requestedPath
+}
+
+// escapesRoot reports whether requestedPath climbs out of the asset root once cleaned.
+// Filesystems such as os.DirFS reject these themselves, but an fs.FS is free not to.
+func escapesRoot(requestedPath string) bool {
+	cleaned := path.Clean(requestedPath)
+	return cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.HasPrefix(cleaned, "/")
+}
+
+// ServeFile returns a handler which always serves the asset at fixedPath regardless of
+// the request URL. FSPrefix, mime inference, and compression are applied as usual.
+func (server *AssetServer) ServeFile(fixedPath string) http.Handler {
+	return server.withMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		server.serve(w, r, fixedPath)
+	})
+}
+
+// guardPath answers r and returns false when requestedPath escapes the root, is held back
+// by Maintenance, or is refused by StrictFilenames or HideDotfiles. Every handler that
+// reads requested paths runs them through it.
+func (server *AssetServer) guardPath(w http.ResponseWriter, r *http.Request, requestedPath string) bool {
+	if escapesRoot(requestedPath) {
+		server.fail(w, r, fs.ErrNotExist)
+		return false
+	}
+	if server.Maintenance != nil {
+		exempt := server.Maintenance.Exempt
+		if exempt == nil || !exempt.MatchString(requestedPath) {
+			server.serveMaintenance(w, r)
+			return false
+		}
+	}
+	if server.StrictFilenames && !portablePath(requestedPath) {
+		server.fail(w, r, fs.ErrNotExist)
+		return false
+	}
+	if server.HideDotfiles && hiddenPath(requestedPath) {
+		server.fail(w, r, fs.ErrNotExist)
+		return false
+	}
+	return true
+}
+
+// serve responds with the asset at requestedPath
+func (server *AssetServer) serve(w http.ResponseWriter, r *http.Request, requestedPath string) {
+	w, done := server.observe(w, r)
+	defer done()
+	if server.CORSOrigin != "" && server.setCORSHeaders(w, r) {
+		return
+	}
+	if !server.methodAllowed(w, r) {
+		return
+	}
+	if malformedPath(r.URL) {
+		server.fail(w, r, ErrBadRequestPath)
+		return
+	}
+	if !server.guardPath(w, r, requestedPath) {
+		return
+	}
+	if server.RedirectFunc != nil {
+		if location, status, ok := server.redirect(r); ok {
+			http.Redirect(w, r, location, status)
+			return
+		}
+	}
+	if requestedPath == "" || strings.HasSuffix(requestedPath, "/") {
+		if server.IndexFile == "" {
+			server.fail(w, r, fs.ErrNotExist)
+			return
+		}
+		requestedPath += server.IndexFile
+	}
+	if original, ok := server.disabledVariant(requestedPath); ok {
+		switch server.DisabledVariants {
+		case DisabledVariantNotFound:
+			server.fail(w, r, fs.ErrNotExist)
+			return
+		case DisabledVariantOriginal:
+			requestedPath = original
+		}
+	}
+	if server.BypassCacheParam != "" && r.URL.Query().Has(server.BypassCacheParam) {
+		server.Invalidate(requestedPath)
+	}
+	if len(server.BotUserAgents) > 0 {
+		w.Header().Add("Vary", "User-Agent")
+		if server.PrerenderDir != "" && server.isBot(r) {
+			prerendered := path.Join(server.PrerenderDir, requestedPath)
+			data, encoding, err := server.readAsset(r, prerendered)
+			if err == nil {
+				server.writeAsset(w, r, requestedPath, data, encoding, server.assetModTime(prerendered))
+				return
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				server.fail(w, r, err)
+				return
+			}
+		}
+	}
+	if server.CacheETags && server.cachedNotModified(w, r, requestedPath) {
+		return
+	}
+	if (server.StreamThreshold > 0 || server.UseServeContent) && server.LanguageNegotiation == nil &&
+		server.streamAsset(w, r, requestedPath) {
+		return
+	}
+	var data []byte
+	var encoding string
+	var err error
+	if server.LanguageNegotiation != nil {
+		data, encoding, requestedPath, err = server.readLanguageVariant(w, r, requestedPath)
+	} else {
+		data, encoding, err = server.readAsset(r, requestedPath)
+	}
+	if errors.Is(err, fs.ErrNotExist) && server.SPAFallback != "" && !server.hasKnownExtension(requestedPath) {
+		requestedPath = server.SPAFallback
+		data, encoding, err = server.readAsset(r, requestedPath)
+	}
 	if err != nil {
-		if server.ErrFunc != nil {
-			server.ErrFunc(w, r, err)
+		server.fail(w, r, err)
+		return
+	}
+	server.writeAsset(w, r, requestedPath, data, encoding, server.assetModTime(requestedPath))
+}
+
+// redirect consults RedirectFunc, defaulting its status to 301
+func (server *AssetServer) redirect(r *http.Request) (string, int, bool) {
+	location, status, ok := server.RedirectFunc(r)
+	if status == 0 {
+		status = http.StatusMovedPermanently
+	}
+	return location, status, ok
+}
+
+// methodAllowed responds with 405 and returns false when the request method is not in
+// AllowedMethods
+func (server *AssetServer) methodAllowed(w http.ResponseWriter, r *http.Request) bool {
+	if len(server.AllowedMethods) == 0 || slices.Contains(server.AllowedMethods, r.Method) {
+		return true
+	}
+	w.Header().Set("Allow", strings.Join(server.AllowedMethods, ", "))
+	server.fail(w, r, fmt.Errorf("%w: %s", ErrMethodNotAllowed, r.Method))
+	return false
+}
+
+// hasKnownExtension reports whether requestedPath ends in an extension claimed by a typer
+func (server *AssetServer) hasKnownExtension(requestedPath string) bool {
+	ext := path.Ext(requestedPath)
+	if ext == "" {
+		return false
+	}
+	_, known := server.extensionOwner(ext)
+	return known
+}
+
+// disabledVariant reports whether requestedPath names a compressed variant whose encoding
+// is not enabled and returns the path of the original it was derived from
+func (server *AssetServer) disabledVariant(requestedPath string) (string, bool) {
+	suffixes := []string{gzipSuffix}
+	if server.BrotliSuffix == "" {
+		suffixes = append(suffixes, brotliSuffix)
+	}
+	if server.ZstdSuffix == "" {
+		suffixes = append(suffixes, zstdSuffix)
+	}
+	for _, suffix := range suffixes {
+		original := strings.TrimSuffix(requestedPath, suffix)
+		if original != requestedPath && original != "" && !strings.HasSuffix(original, "/") {
+			return original, true
 		}
+	}
+	return "", false
+}
+
+// ServeBytes responds with data as if it had been read from the asset filesystem at name.
+// Mime inference, HeaderFunc, and content negotiation are applied as they are by ServeHTTP.
+// No Last-Modified is sent, even when a file exists at name, since data isn't that file.
+func (server *AssetServer) ServeBytes(w http.ResponseWriter, r *http.Request, name string, data []byte) {
+	server.writeAsset(w, r, name, data, "", time.Time{})
+}
+
+// fail reports err to the client via ErrFunc
+func (server *AssetServer) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if server.ErrorLogFunc != nil {
+		server.ErrorLogFunc(r, err)
+	}
+	if server.NotFoundFile != "" && errors.Is(err, fs.ErrNotExist) && server.serveNotFound(w, r) {
+		return
+	}
+	if server.ErrFunc != nil {
+		server.ErrFunc(w, r, err)
+	}
+}
+
+// writeAsset writes a successful response for the asset at requestedPath. encoding is the
+// content coding of data, or empty when it is uncompressed. modTime dates data for
+// Last-Modified and If-Modified-Since, and is zero when it isn't known.
+func (server *AssetServer) writeAsset(w http.ResponseWriter, r *http.Request, requestedPath string, data []byte, encoding string, modTime time.Time) {
+	acceptEncoding := r.Header.Get("Accept-Encoding")
+	// Precompressed variants are never transformed, whether sent as is or decoded
+	variant := encoding != ""
+	decodeDirect := encoding == brotliEncoding && server.DecodeDirectVariantRequests &&
+		strings.HasSuffix(requestedPath, server.BrotliSuffix) && !acceptsEncoding(acceptEncoding, brotliEncoding)
+	// Variants reach clients which can't take them when requested directly or as the only
+	// copy, so those insisting on identity get decoded bytes rather than a coding they refused
+	if encoding != "" && (decodeDirect || identityOnly(acceptEncoding) || encodingRefused(acceptEncoding, encoding)) {
+		if encoding != brotliEncoding {
+			server.fail(w, r, ErrNotAcceptable)
+			return
+		}
+		decoded, err := decodeBrotli(data)
+		if err != nil {
+			server.fail(w, r, err)
+			return
+		}
+		data, encoding = decoded, ""
+	}
+	mimeType := server.ForceContentType
+	if mimeType == "" {
+		mimeType = server.detectMimeType(requestedPath, data, encoding)
+	}
+	if encoding == "" {
+		if !variant {
+			data = server.applyTransforms(requestedPath, mimeType, data)
+		}
+		if server.gzipAllowed(r, mimeType, data) {
+			compressed, err := gzipCompress(data, server.GzipLevel)
+			if err != nil {
+				server.fail(w, r, err)
+				return
+			}
+			data, encoding = compressed, gzipEncoding
+		}
+	}
+	if encoding == "" && identityRefused(acceptEncoding) {
+		// The client refuses the uncompressed representation and no
+		// compressed variant was found
+		server.fail(w, r, ErrNotAcceptable)
 		return
 	}
 	if server.HeaderFunc != nil {
 		server.HeaderFunc(w, data)
 	}
-	w.Header().Add("Content-Type", server.inferMimeType(requestedPath))
-	if isBrotli {
-		w.Header().Add("Content-Encoding", brotliEncoding)
+	server.setCacheControl(w, requestedPath)
+	if server.AssetHeaderFunc != nil {
+		server.AssetHeaderFunc(w, r, AssetInfo{Path: requestedPath, Data: data, modTime: modTime})
+	}
+	w.Header().Add("Content-Type", mimeType)
+	server.setDisposition(w, requestedPath)
+	if server.SaveDataSuffix != "" {
+		w.Header().Add("Vary", "Save-Data")
+	}
+	if language := server.pathLanguage(requestedPath); language != "" {
+		w.Header().Set("Content-Language", language)
+	}
+	if server.TimingAllowOrigin != "" {
+		w.Header().Set("Timing-Allow-Origin", server.TimingAllowOrigin)
+	}
+	// Set, never Add, so a coding from HeaderFunc or upstream can't stack on ours or
+	// claim one for uncompressed bytes
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	} else {
+		w.Header().Del("Content-Encoding")
+	}
+	if server.negotiatesEncoding() {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if !server.LastModified {
+		modTime = time.Time{}
+	}
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if server.ETagFunc != nil {
+		etag := server.ETagFunc(data)
+		w.Header().Set("ETag", etag)
+		if server.CacheETags {
+			server.rememberETag(requestedPath, etag, w.Header())
+		}
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	// If-None-Match takes precedence, so If-Modified-Since is only consulted without it
+	if r.Header.Get("If-None-Match") == "" && notModifiedSince(r.Header.Get("If-Modified-Since"), modTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if server.rangeAllowed(mimeType) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Header.Get("Range") != "" {
+			http.ServeContent(w, r, requestedPath, modTime, bytes.NewReader(data))
+			return
+		}
 	}
+	// data is fully in memory, so the length is known before the status is sent
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
 	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	server.write(w, r, requestedPath, data)
+}
+
+// rangeAllowed reports whether mimeType matches one of RangeTypes
+func (server *AssetServer) rangeAllowed(mimeType string) bool {
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, rangeType := range server.RangeTypes {
+		rangeType = strings.ToLower(rangeType)
+		if prefix, found := strings.CutSuffix(rangeType, "*"); found && strings.HasSuffix(prefix, "/") {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+		} else if mediaType == rangeType {
+			return true
+		}
+	}
+	return false
+}
+
+// write sends the response body. Write errors, e.g. from a client resetting an HTTP/2
+// stream, can't change the already sent status so they are passed to ErrorLogFunc.
+// HEAD requests get their headers, including Content-Length, but no body.
+func (server *AssetServer) write(w http.ResponseWriter, r *http.Request, requestedPath string, data []byte) (int, error) {
+	if r.Method == http.MethodHead {
+		return 0, nil
+	}
+	n, err := w.Write(data)
+	if err != nil && server.ErrorLogFunc != nil {
+		server.ErrorLogFunc(r, fmt.Errorf("writing %s after %d of %d bytes: %w", requestedPath, n, len(data), err))
+	}
+	return n, err
 }
@@ -15,13 +15,17 @@
 package statica
 
 import (
+	"compress/gzip"
+	"context"
 	"errors"
 	"io/fs"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
+	"strconv"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,6 +48,7 @@ var testFiles = fstest.MapFS{
 	"prefix/nested/style.css": &fstest.MapFile{Data: []byte("prefixed css")},
 	"prefix/script.js":        &fstest.MapFile{Data: []byte("prefixed js")},
 	"only-brotli.js.br":       &fstest.MapFile{Data: []byte("only-brotli-content")},
+	"maintenance.html":        &fstest.MapFile{Data: []byte("<h1>Back soon</h1>")},
 }
 
 func TestNewAssetServer(t *testing.T) {
@@ -63,6 +68,20 @@ func TestNewAssetServer(t *testing.T) {
 		assert.Equal(t, ErrEmptyRoute, err)
 	})
 
+	t.Run("Bad route", func(t *testing.T) {
+		for _, route := range []string{"assets/", "assets", "/assets"} {
+			server, err := NewAssetServer(route, testFiles)
+			assert.Nil(t, server, route)
+			assert.Equal(t, ErrBadRoute, err, route)
+		}
+	})
+
+	t.Run("Root route", func(t *testing.T) {
+		server, err := NewAssetServer("/", testFiles)
+		require.Nil(t, err)
+		assert.Nil(t, server.Check())
+	})
+
 	t.Run("Nil filesystem", func(t *testing.T) {
 		server, err := NewAssetServer("/assets/", nil)
 		assert.Nil(t, server)
@@ -78,15 +97,23 @@ func TestMimeTypeInference(t *testing.T) {
 		file     string
 		expected string
 	}{
-		{"CSS", "style.css", mimeTypeCSS},
-		{"JavaScript", "script.js", mimeTypeJS},
+		{"CSS", "style.css", utf8Type(mimeTypeCSS)},
+		{"JavaScript", "script.js", utf8Type(mimeTypeJS)},
 		{"PNG", "image.png", mimeTypePNG},
 		{"WOFF2", "font.woff2", mimeTypeWOFF2},
 		{"WOFF", "font.woff", mimeTypeWOFF},
 		{"JPEG", "image.jpeg", mimeTypeJPG},
 		{"JPG", "image.jpg", mimeTypeJPG},
-		{"JSON", "data.json", mimeTypeJSON},
-		{"Text", "file.txt", mimeTypeText},
+		{"JSON", "data.json", utf8Type(mimeTypeJSON)},
+		{"Text", "file.txt", utf8Type(mimeTypeText)},
+		{"WASM", "module.wasm", mimeTypeWASM},
+		{"SVG", "logo.svg", utf8Type(mimeTypeSVG)},
+		{"WebP", "photo.webp", mimeTypeWebP},
+		{"AVIF", "photo.avif", mimeTypeAVIF},
+		{"MP4", "clip.mp4", mimeTypeMP4},
+		{"ICO", "favicon.ico", mimeTypeICO},
+		{"Source map", "app.js.map", utf8Type(mimeTypeJSON)},
+		{"Source map of a stylesheet", "site.css.map", utf8Type(mimeTypeJSON)},
 		{"Unknown", "file.xyz", mimeTypeUnknown},
 	}
 
@@ -103,13 +130,13 @@ func TestRegisterMimeType(t *testing.T) {
 	require.Nil(t, err)
 
 	t.Run("Register new mime type", func(t *testing.T) {
-		success := server.RegisterMimeType(regexp.MustCompile(`\.svg$`), "image/svg+xml", false)
+		success := server.RegisterMimeType(regexp.MustCompile(`\.heic$`), "image/heic", false)
 		assert.True(t, success)
-		assert.True(t, server.IsMimeTypeRegistered("image/svg+xml"))
+		assert.True(t, server.IsMimeTypeRegistered("image/heic"))
 	})
 
 	t.Run("Register duplicate mime type", func(t *testing.T) {
-		success := server.RegisterMimeType(regexp.MustCompile(`\.svg2$`), "image/svg+xml", false)
+		success := server.RegisterMimeType(regexp.MustCompile(`\.heic2$`), "image/heic", false)
 		assert.False(t, success)
 	})
 
@@ -120,6 +147,70 @@ func TestRegisterMimeType(t *testing.T) {
 	})
 }
 
+func TestRegisterMimeTypeExprs(t *testing.T) {
+	yaml := []*regexp.Regexp{regexp.MustCompile(`\.yml$`), regexp.MustCompile(`\.yaml$`)}
+
+	t.Run("Several extensions for one type", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		require.True(t, server.RegisterMimeTypeExprs(yaml, "application/yaml", false))
+
+		assert.Equal(t, "application/yaml", server.inferMimeType("config.yml"))
+		assert.Equal(t, "application/yaml", server.inferMimeType("config.yaml"))
+		assert.False(t, server.RegisterMimeTypeExprs(yaml, "application/yaml", false))
+	})
+
+	t.Run("Priority keeps pattern order ahead of defaults", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		modules := []*regexp.Regexp{regexp.MustCompile(`\.mjs$`), regexp.MustCompile(`\.js$`)}
+		require.True(t, server.RegisterMimeTypeExprs(modules, "application/x-module", true))
+
+		mappings := server.ListMimeTypes()
+		assert.Equal(t, `\.mjs$`, mappings[0].Expr.String())
+		assert.Equal(t, `\.js$`, mappings[1].Expr.String())
+		assert.Equal(t, "application/x-module", server.inferMimeType("app.js"))
+	})
+
+	t.Run("Conflicting extension refuses every pattern", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		before := len(server.typers)
+		exprs := []*regexp.Regexp{regexp.MustCompile(`\.mjs$`), regexp.MustCompile(`\.css$`)}
+
+		assert.False(t, server.RegisterMimeTypeExprs(exprs, "text/x-other", false))
+		assert.Len(t, server.typers, before)
+	})
+
+	t.Run("MaxTypers counts each pattern", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.MaxTypers = len(server.typers) + 1
+
+		assert.False(t, server.RegisterMimeTypeExprs(yaml, "application/yaml", false))
+		assert.True(t, server.RegisterMimeTypeExprs(yaml[:1], "application/yaml", false))
+	})
+
+	t.Run("Empty or nil patterns", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+
+		assert.False(t, server.RegisterMimeTypeExprs(nil, "application/yaml", false))
+		assert.False(t, server.RegisterMimeTypeExprs([]*regexp.Regexp{yaml[0], nil}, "application/yaml", false))
+		assert.False(t, server.IsMimeTypeRegistered("application/yaml"))
+	})
+
+	t.Run("RemoveMimeType removes every pattern", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		require.True(t, server.RegisterMimeTypeExprs(yaml, "application/yaml", false))
+
+		assert.True(t, server.RemoveMimeType("application/yaml"))
+		assert.Equal(t, mimeTypeUnknown, server.inferMimeType("config.yml"))
+		assert.Equal(t, mimeTypeUnknown, server.inferMimeType("config.yaml"))
+	})
+}
+
 func TestRemoveMimeType(t *testing.T) {
 	server, err := NewAssetServer("/assets/", testFiles)
 	require.Nil(t, err)
@@ -151,14 +242,14 @@ func TestServeHTTP(t *testing.T) {
 			name:           "Serve CSS file",
 			path:           "/assets/test.css",
 			expectedStatus: http.StatusOK,
-			expectedType:   mimeTypeCSS,
+			expectedType:   utf8Type(mimeTypeCSS),
 			expectedBody:   "body { color: blue; }",
 		},
 		{
 			name:           "Serve JS file",
 			path:           "/assets/test.js",
 			expectedStatus: http.StatusOK,
-			expectedType:   mimeTypeJS,
+			expectedType:   utf8Type(mimeTypeJS),
 			expectedBody:   "console.log('test');",
 		},
 		{
@@ -166,7 +257,7 @@ func TestServeHTTP(t *testing.T) {
 			path:           "/assets/nonexistent.txt",
 			expectedStatus: http.StatusNotFound,
 			expectedType:   "text/plain",
-			expectedBody:   "open nonexistent.txt: file does not exist",
+			expectedBody:   "Not Found",
 		},
 		{
 			name:           "Unknown file type",
@@ -198,16 +289,59 @@ func TestBrotliSupport(t *testing.T) {
 
 	t.Run("Normal file with brotli variant", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
 		w := httptest.NewRecorder()
 
 		server.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, mimeTypeCSS, w.Header().Get("Content-Type"))
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
 		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
 		assert.Equal(t, "compressed-css-data", w.Body.String())
 	})
 
+	t.Run("Client without br gets uncompressed file", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "body { color: blue; }", w.Body.String())
+	})
+
+	t.Run("Client listing other encodings gets uncompressed file", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "body { color: blue; }", w.Body.String())
+	})
+
+	t.Run("Variant without original is still served", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/only-brotli.js", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Variant without original when br is refused", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/only-brotli.js", nil)
+		req.Header.Set("Accept-Encoding", "gzip, br;q=0")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
 	t.Run("Direct request to .br file", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/assets/test.css.br", nil)
 		w := httptest.NewRecorder()
@@ -216,9 +350,9 @@ func TestBrotliSupport(t *testing.T) {
 
 		// The server should serve the compressed content with correct headers
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, mimeTypeCSS, w.Header().Get("Content-Type"))        // Should use original file's mime type
-		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding")) // Mark as brotli compressed
-		assert.Equal(t, "compressed-css-data", w.Body.String())             // Send compressed content for client to decompress
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type")) // Should use original file's mime type
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))    // Mark as brotli compressed
+		assert.Equal(t, "compressed-css-data", w.Body.String())                // Send compressed content for client to decompress
 	})
 }
 
@@ -278,6 +412,16 @@ func TestDefaultErrFunc(t *testing.T) {
 			err:            fs.ErrPermission,
 			expectedStatus: http.StatusForbidden,
 		},
+		{
+			name:           "Not Acceptable Error",
+			err:            ErrNotAcceptable,
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:           "Method Not Allowed Error",
+			err:            ErrMethodNotAllowed,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
 		{
 			name:           "Other Error",
 			err:            errors.New("unknown error"),
@@ -293,9 +437,27 @@ func TestDefaultErrFunc(t *testing.T) {
 			DefaultErrFunc(w, r, tt.err)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
-			assert.Equal(t, tt.err.Error(), w.Body.String())
+			assert.Equal(t, http.StatusText(tt.expectedStatus), w.Body.String())
 		})
 	}
+
+	t.Run("Body omits the underlying error", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		var logged error
+		server.ErrorLogFunc = func(r *http.Request, err error) {
+			logged = err
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/private/missing.txt", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "Not Found", w.Body.String())
+		assert.NotContains(t, w.Body.String(), "private/missing.txt")
+		require.Error(t, logged)
+		assert.ErrorIs(t, logged, fs.ErrNotExist)
+		assert.Contains(t, logged.Error(), "private/missing.txt")
+	})
 }
 
 func TestCheck(t *testing.T) {
@@ -314,6 +476,22 @@ func TestCheck(t *testing.T) {
 		assert.Equal(t, ErrEmptyRoute, err)
 	})
 
+	t.Run("Route missing leading slash", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.route = "assets/"
+		err = server.Check()
+		assert.Equal(t, ErrBadRoute, err)
+	})
+
+	t.Run("Route missing trailing slash", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.route = "/assets"
+		err = server.Check()
+		assert.Equal(t, ErrBadRoute, err)
+	})
+
 	t.Run("Nil filesystem", func(t *testing.T) {
 		server, err := NewAssetServer("/assets/", testFiles)
 		require.Nil(t, err)
@@ -330,6 +508,21 @@ func TestCheck(t *testing.T) {
 		assert.Equal(t, ErrBadBrotliSuffix, err)
 	})
 
+	t.Run("Bad zstd suffix - no dot prefix", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.ZstdSuffix = "zst"
+		err = server.Check()
+		assert.Equal(t, ErrBadZstdSuffix, err)
+	})
+
+	t.Run("Good zstd suffix", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.ZstdSuffix = ".zst"
+		assert.Nil(t, server.Check())
+	})
+
 	t.Run("Good Brotli suffix", func(t *testing.T) {
 		server, err := NewAssetServer("/assets/", testFiles)
 		require.Nil(t, err)
@@ -338,6 +531,30 @@ func TestCheck(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("Default gzip level", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.Equal(t, gzip.DefaultCompression, server.GzipLevel)
+	})
+
+	t.Run("Valid gzip levels", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		for _, level := range []int{gzip.HuffmanOnly, gzip.DefaultCompression, gzip.NoCompression, gzip.BestSpeed, gzip.BestCompression} {
+			server.GzipLevel = level
+			assert.Nil(t, server.Check(), "level %d", level)
+		}
+	})
+
+	t.Run("Bad gzip level", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		for _, level := range []int{gzip.HuffmanOnly - 1, gzip.BestCompression + 1} {
+			server.GzipLevel = level
+			assert.Equal(t, ErrBadGzipLevel, server.Check(), "level %d", level)
+		}
+	})
+
 	t.Run("Absolute FSPrefix", func(t *testing.T) {
 		server, err := NewAssetServer("/assets/", testFiles)
 		require.Nil(t, err)
@@ -363,6 +580,53 @@ func TestCheck(t *testing.T) {
 	})
 }
 
+func TestZstdSupport(t *testing.T) {
+	files := fstest.MapFS{
+		"app.js":       &fstest.MapFile{Data: []byte("plain js")},
+		"app.js.zst":   &fstest.MapFile{Data: []byte("zstd js")},
+		"app.js.br":    &fstest.MapFile{Data: []byte("brotli js")},
+		"style.css":    &fstest.MapFile{Data: []byte("plain css")},
+		"style.css.br": &fstest.MapFile{Data: []byte("brotli css")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.BrotliSuffix = ".br"
+	server.ZstdSuffix = ".zst"
+
+	tests := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		expectedEncoding string
+		expectedBody     string
+	}{
+		{"Both variants, client accepts both", "/assets/app.js", "gzip, br, zstd", zstdEncoding, "zstd js"},
+		{"Both variants, client accepts br", "/assets/app.js", "gzip, br", brotliEncoding, "brotli js"},
+		{"Both variants, client accepts zstd", "/assets/app.js", "zstd", zstdEncoding, "zstd js"},
+		{"Both variants, client accepts neither", "/assets/app.js", "gzip", "", "plain js"},
+		{"Only brotli variant, client accepts both", "/assets/style.css", "br, zstd", brotliEncoding, "brotli css"},
+		{"Only brotli variant, client accepts zstd", "/assets/style.css", "zstd", "", "plain css"},
+		{"Direct zstd request", "/assets/app.js.zst", "", zstdEncoding, "zstd js"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.NotEqual(t, mimeTypeUnknown, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedEncoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
 func TestFSPrefix(t *testing.T) {
 	server, err := NewAssetServer("/assets/", testFiles)
 	require.Nil(t, err)
@@ -375,7 +639,7 @@ func TestFSPrefix(t *testing.T) {
 		server.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, mimeTypeJS, w.Header().Get("Content-Type"))
+		assert.Equal(t, utf8Type(mimeTypeJS), w.Header().Get("Content-Type"))
 		assert.Equal(t, "prefixed js", w.Body.String())
 	})
 
@@ -386,7 +650,7 @@ func TestFSPrefix(t *testing.T) {
 		server.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, mimeTypeCSS, w.Header().Get("Content-Type"))
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
 		assert.Equal(t, "prefixed css", w.Body.String())
 	})
 
@@ -401,139 +665,470 @@ func TestFSPrefix(t *testing.T) {
 
 	t.Run("MIME type inference with FSPrefix", func(t *testing.T) {
 		mimeType := server.inferMimeType("script.js")
-		assert.Equal(t, mimeTypeJS, mimeType)
+		assert.Equal(t, utf8Type(mimeTypeJS), mimeType)
 	})
 
 	t.Run("MIME type inference with FSPrefix and Brotli", func(t *testing.T) {
 		server.BrotliSuffix = ".br"
 		mimeType := server.inferMimeType("script.js.br")
-		assert.Equal(t, mimeTypeJS, mimeType)
+		assert.Equal(t, utf8Type(mimeTypeJS), mimeType)
 	})
 }
 
-func TestBrotliEdgeCases(t *testing.T) {
-	t.Run("File with only brotli variant gets served", func(t *testing.T) {
-		server, err := NewAssetServer("/assets/", testFiles)
-		require.Nil(t, err)
-		server.BrotliSuffix = ".br"
+func TestContentLength(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		brotliSuffix string
+		fsPrefix     string
+	}{
+		{"Plain file", "/assets/test.json", "", ""},
+		{"Brotli variant", "/assets/test.css", ".br", ""},
+		{"FSPrefix", "/assets/nested/style.css", "", "prefix/"},
+	}
 
-		req := httptest.NewRequest("GET", "/assets/only-brotli.js", nil)
-		w := httptest.NewRecorder()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", testFiles)
+			require.Nil(t, err)
+			server.BrotliSuffix = tt.brotliSuffix
+			server.FSPrefix = tt.fsPrefix
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
 
-		server.ServeHTTP(w, req)
+			server.ServeHTTP(w, req)
 
-		// The readFile method tries brotli first, so this succeeds
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, mimeTypeJS, w.Header().Get("Content-Type"))
-		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
-		assert.Equal(t, "only-brotli-content", w.Body.String())
-	})
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, strconv.Itoa(len(w.Body.Bytes())), w.Header().Get("Content-Length"))
+		})
+	}
 
-	t.Run("Direct request to brotli file when original doesn't exist", func(t *testing.T) {
+	t.Run("Length reflects transformed content", func(t *testing.T) {
 		server, err := NewAssetServer("/assets/", testFiles)
 		require.Nil(t, err)
-		server.BrotliSuffix = ".br"
-
-		req := httptest.NewRequest("GET", "/assets/only-brotli.js.br", nil)
+		server.Transforms = []StaticaTransformFunc{func(filePath, mimeType string, data []byte) []byte {
+			return append(data, " /* footer */"...)
+		}}
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
 		w := httptest.NewRecorder()
 
 		server.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, mimeTypeJS, w.Header().Get("Content-Type"))
-		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
-		assert.Equal(t, "only-brotli-content", w.Body.String())
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, strconv.Itoa(len(w.Body.Bytes())), w.Header().Get("Content-Length"))
 	})
+}
 
-	t.Run("File without brotli variant falls back to original", func(t *testing.T) {
-		server, err := NewAssetServer("/assets/", testFiles)
-		require.Nil(t, err)
-		server.BrotliSuffix = ".br"
+func TestDisabledVariants(t *testing.T) {
+	files := fstest.MapFS{
+		"app.css":        &fstest.MapFile{Data: []byte("plain css")},
+		"app.css.br":     &fstest.MapFile{Data: []byte("brotli css")},
+		"bundle.js":      &fstest.MapFile{Data: []byte("plain js")},
+		"bundle.js.gz":   &fstest.MapFile{Data: []byte("gzip js")},
+		"release.tar.gz": &fstest.MapFile{Data: []byte("archive")},
+	}
 
-		req := httptest.NewRequest("GET", "/assets/test.txt", nil)
+	tests := []struct {
+		name           string
+		policy         DisabledVariantPolicy
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Literal brotli read", DisabledVariantLiteral, "/assets/app.css.br", http.StatusOK, "brotli css"},
+		{"Literal gzip read", DisabledVariantLiteral, "/assets/release.tar.gz", http.StatusOK, "archive"},
+		{"Not found brotli", DisabledVariantNotFound, "/assets/app.css.br", http.StatusNotFound, ""},
+		{"Not found gzip", DisabledVariantNotFound, "/assets/bundle.js.gz", http.StatusNotFound, ""},
+		{"Original for brotli", DisabledVariantOriginal, "/assets/app.css.br", http.StatusOK, "plain css"},
+		{"Original for gzip", DisabledVariantOriginal, "/assets/bundle.js.gz", http.StatusOK, "plain js"},
+		{"Original missing", DisabledVariantOriginal, "/assets/release.tar.gz", http.StatusNotFound, ""},
+		{"Plain files unaffected", DisabledVariantNotFound, "/assets/app.css", http.StatusOK, "plain css"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", files)
+			require.Nil(t, err)
+			server.DisabledVariants = tt.policy
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+				assert.Empty(t, w.Header().Get("Content-Encoding"))
+			}
+		})
+	}
+
+	t.Run("Original is served with its own mime type", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.DisabledVariants = DisabledVariantOriginal
+		req := httptest.NewRequest("GET", "/assets/app.css.br", nil)
 		w := httptest.NewRecorder()
 
 		server.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, mimeTypeText, w.Header().Get("Content-Type"))
-		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
-		assert.Equal(t, "plain text", w.Body.String())
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
 	})
 
-	t.Run("Empty BrotliSuffix disables brotli", func(t *testing.T) {
-		server, err := NewAssetServer("/assets/", testFiles)
+	t.Run("Enabled brotli suffix is not a disabled variant", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
 		require.Nil(t, err)
-		// BrotliSuffix is empty by default, no need to set it
-
-		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		server.BrotliSuffix = ".br"
+		server.DisabledVariants = DisabledVariantNotFound
+		req := httptest.NewRequest("GET", "/assets/app.css.br", nil)
 		w := httptest.NewRecorder()
 
 		server.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
-		assert.Equal(t, "body { color: blue; }", w.Body.String())
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "brotli css", w.Body.String())
 	})
 }
 
-func TestServeHTTPNilHandlers(t *testing.T) {
-	server, err := NewAssetServer("/assets/", testFiles)
-	require.Nil(t, err)
+func TestHEADRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+	}{
+		{"Plain file", "/assets/test.css", ""},
+		{"Brotli variant", "/assets/test.css", "br"},
+		{"Unknown type", "/assets/test.unknown", ""},
+	}
 
-	t.Run("Nil ErrFunc", func(t *testing.T) {
-		server.ErrFunc = nil
-		req := httptest.NewRequest("GET", "/assets/nonexistent.txt", nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", testFiles)
+			require.Nil(t, err)
+			server.BrotliSuffix = ".br"
+			server.HeaderFunc = DefaultHeaderFunc
+
+			get := httptest.NewRequest("GET", tt.path, nil)
+			head := httptest.NewRequest("HEAD", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				get.Header.Set("Accept-Encoding", tt.acceptEncoding)
+				head.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			getRecorder := httptest.NewRecorder()
+			headRecorder := httptest.NewRecorder()
+
+			server.ServeHTTP(getRecorder, get)
+			server.ServeHTTP(headRecorder, head)
+
+			assert.Equal(t, http.StatusOK, headRecorder.Code)
+			assert.Empty(t, headRecorder.Body.Bytes())
+			assert.Equal(t, strconv.Itoa(getRecorder.Body.Len()), headRecorder.Header().Get("Content-Length"))
+			assert.Equal(t, getRecorder.Header(), headRecorder.Header())
+		})
+	}
+
+	t.Run("Missing file", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		req := httptest.NewRequest("HEAD", "/assets/missing.css", nil)
 		w := httptest.NewRecorder()
 
 		server.ServeHTTP(w, req)
 
-		// Should return without error, status would be 200 by default
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "", w.Body.String())
+		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 
-	t.Run("Nil HeaderFunc", func(t *testing.T) {
-		server.HeaderFunc = nil
-		req := httptest.NewRequest("GET", "/assets/test.txt", nil)
+	t.Run("Maintenance page", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.Maintenance = &MaintenanceConfig{File: "maintenance.html"}
+		req := httptest.NewRequest("HEAD", "/assets/test.css", nil)
 		w := httptest.NewRecorder()
 
 		server.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "plain text", w.Body.String())
-		// Should not have custom headers but still have Content-Type
-		assert.Equal(t, mimeTypeText, w.Header().Get("Content-Type"))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Empty(t, w.Body.Bytes())
 	})
 }
 
-func TestRemoveMimeTypeEdgeCases(t *testing.T) {
-	server, err := NewAssetServer("/assets/", testFiles)
-	require.Nil(t, err)
+func TestRangeTypes(t *testing.T) {
+	files := fstest.MapFS{
+		"clip.mp4":  &fstest.MapFile{Data: []byte("0123456789")},
+		"notes.txt": &fstest.MapFile{Data: []byte("0123456789")},
+		"doc.pdf":   &fstest.MapFile{Data: []byte("0123456789")},
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.pdf$`), "application/pdf", false))
+		return server
+	}
 
-	t.Run("Remove first element", func(t *testing.T) {
-		originalFirst := server.typers[0].mimeType
-		success := server.RemoveMimeType(originalFirst)
-		assert.True(t, success)
-		assert.False(t, server.IsMimeTypeRegistered(originalFirst))
-		assert.True(t, len(server.typers) > 0) // Should still have other typers
+	t.Run("Media type serves ranges", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/clip.mp4", nil)
+		req.Header.Set("Range", "bytes=2-5")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusPartialContent, recorder.Code)
+		assert.Equal(t, "2345", recorder.Body.String())
+		assert.Equal(t, "bytes", recorder.Header().Get("Accept-Ranges"))
+		assert.Equal(t, "bytes 2-5/10", recorder.Header().Get("Content-Range"))
+		assert.Equal(t, "video/mp4", recorder.Header().Get("Content-Type"))
 	})
 
-	t.Run("Remove last element", func(t *testing.T) {
-		server, _ := NewAssetServer("/assets/", testFiles) // Fresh server
-		lastIndex := len(server.typers) - 1
-		originalLast := server.typers[lastIndex].mimeType
-		success := server.RemoveMimeType(originalLast)
-		assert.True(t, success)
-		assert.False(t, server.IsMimeTypeRegistered(originalLast))
-		assert.True(t, len(server.typers) > 0) // Should still have other typers
+	t.Run("Media type without Range", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/clip.mp4", nil)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "0123456789", recorder.Body.String())
+		assert.Equal(t, "bytes", recorder.Header().Get("Accept-Ranges"))
+	})
+
+	t.Run("Unsatisfiable range", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/clip.mp4", nil)
+		req.Header.Set("Range", "bytes=20-30")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, recorder.Code)
+	})
+
+	t.Run("Other types are served whole", func(t *testing.T) {
+		server := newServer(t)
+		req := httptest.NewRequest("GET", "/assets/notes.txt", nil)
+		req.Header.Set("Range", "bytes=2-5")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "0123456789", recorder.Body.String())
+		assert.Empty(t, recorder.Header().Get("Accept-Ranges"))
+	})
+
+	t.Run("Exact types", func(t *testing.T) {
+		server := newServer(t)
+		server.RangeTypes = []string{"application/pdf", "text/plain"}
+		for _, p := range []string{"/assets/doc.pdf", "/assets/notes.txt"} {
+			req := httptest.NewRequest("GET", p, nil)
+			req.Header.Set("Range", "bytes=0-1")
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusPartialContent, recorder.Code, p)
+			assert.Equal(t, "01", recorder.Body.String(), p)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		server := newServer(t)
+		server.RangeTypes = nil
+		req := httptest.NewRequest("GET", "/assets/clip.mp4", nil)
+		req.Header.Set("Range", "bytes=2-5")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Accept-Ranges"))
+	})
+}
+
+func TestAllowedMethods(t *testing.T) {
+	tests := []struct {
+		method         string
+		expectedStatus int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodHead, http.StatusOK},
+		{http.MethodPost, http.StatusMethodNotAllowed},
+		{http.MethodDelete, http.StatusMethodNotAllowed},
+		{http.MethodOptions, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", testFiles)
+			require.Nil(t, err)
+			req := httptest.NewRequest(tt.method, "/assets/test.css", nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusMethodNotAllowed {
+				assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+			} else {
+				assert.Empty(t, w.Header().Get("Allow"))
+			}
+		})
+	}
+
+	t.Run("Custom methods", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.AllowedMethods = append(server.AllowedMethods, http.MethodOptions)
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/assets/test.css", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/assets/test.css", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Allow"))
+	})
+
+	t.Run("Empty allows any method", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.AllowedMethods = nil
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/assets/test.css", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Bundles are restricted too", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		w := httptest.NewRecorder()
+
+		server.BundleHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/assets/bundle?files=test.css", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestBrotliEdgeCases(t *testing.T) {
+	t.Run("File with only brotli variant gets served", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+
+		req := httptest.NewRequest("GET", "/assets/only-brotli.js", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		// The readFile method tries brotli first, so this succeeds
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeJS), w.Header().Get("Content-Type"))
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "only-brotli-content", w.Body.String())
+	})
+
+	t.Run("Direct request to brotli file when original doesn't exist", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+
+		req := httptest.NewRequest("GET", "/assets/only-brotli.js.br", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeJS), w.Header().Get("Content-Type"))
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "only-brotli-content", w.Body.String())
+	})
+
+	t.Run("File without brotli variant falls back to original", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+
+		req := httptest.NewRequest("GET", "/assets/test.txt", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeText), w.Header().Get("Content-Type"))
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "plain text", w.Body.String())
+	})
+
+	t.Run("Empty BrotliSuffix disables brotli", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		// BrotliSuffix is empty by default, no need to set it
+
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "body { color: blue; }", w.Body.String())
+	})
+}
+
+func TestServeHTTPNilHandlers(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+
+	t.Run("Nil ErrFunc", func(t *testing.T) {
+		server.ErrFunc = nil
+		req := httptest.NewRequest("GET", "/assets/nonexistent.txt", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		// Should return without error, status would be 200 by default
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "", w.Body.String())
+	})
+
+	t.Run("Nil HeaderFunc", func(t *testing.T) {
+		server.HeaderFunc = nil
+		req := httptest.NewRequest("GET", "/assets/test.txt", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "plain text", w.Body.String())
+		// Should not have custom headers but still have Content-Type
+		assert.Equal(t, utf8Type(mimeTypeText), w.Header().Get("Content-Type"))
+	})
+}
+
+func TestRemoveMimeTypeEdgeCases(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+
+	t.Run("Remove first element", func(t *testing.T) {
+		originalFirst := server.typers[0].mimeType
+		success := server.RemoveMimeType(originalFirst)
+		assert.True(t, success)
+		assert.False(t, server.IsMimeTypeRegistered(originalFirst))
+		assert.True(t, len(server.typers) > 0) // Should still have other typers
+	})
+
+	t.Run("Remove last element", func(t *testing.T) {
+		server, _ := NewAssetServer("/assets/", testFiles) // Fresh server
+		lastIndex := len(server.typers) - 1
+		originalLast := server.typers[lastIndex].mimeType
+		success := server.RemoveMimeType(originalLast)
+		assert.True(t, success)
+		assert.False(t, server.IsMimeTypeRegistered(originalLast))
+		assert.True(t, len(server.typers) > 0) // Should still have other typers
 	})
 
 	t.Run("Remove middle element", func(t *testing.T) {
 		server, _ := NewAssetServer("/assets/", testFiles) // Fresh server
 		if len(server.typers) >= 3 {
-			middleIndex := len(server.typers) / 2
-			originalMiddle := server.typers[middleIndex].mimeType
+			originalMiddle := middleTyper(server).mimeType
 			originalLength := len(server.typers)
 			success := server.RemoveMimeType(originalMiddle)
 			assert.True(t, success)
@@ -556,6 +1151,232 @@ func TestRemoveMimeTypeEdgeCases(t *testing.T) {
 	})
 }
 
+// middleTyper returns the typer nearest the middle of the list which is the only one for
+// its mime type, so removing and re-registering it restores the list
+func middleTyper(server *AssetServer) mimeTyper {
+	for offset := range len(server.typers) {
+		typer := server.typers[(len(server.typers)/2+offset)%len(server.typers)]
+		count := 0
+		for _, other := range server.typers {
+			if other.mimeType == typer.mimeType {
+				count++
+			}
+		}
+		if count == 1 {
+			return typer
+		}
+	}
+	panic("every mime type has several typers")
+}
+
+func TestMimeTypeChurn(t *testing.T) {
+	t.Run("Repeated add and remove cycles", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		originalLength := len(server.typers)
+		middle := middleTyper(server)
+
+		for i := 0; i < 50; i++ {
+			require.True(t, server.RemoveMimeType(middle.mimeType))
+			require.True(t, server.RegisterMimeType(middle.expr, middle.mimeType, i%2 == 0))
+		}
+		assert.Equal(t, originalLength, len(server.typers))
+		assert.Equal(t, utf8Type(mimeTypeCSS), server.inferMimeType("style.css"))
+		assert.Equal(t, utf8Type(mimeTypeHTML), server.inferMimeType("index.html"))
+		ext, ok := simpleExtension(middle.expr)
+		require.True(t, ok)
+		assert.Equal(t, server.withCharset(middle.mimeType), server.inferMimeType("file."+ext))
+	})
+
+	t.Run("Removal leaves earlier slices intact", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		snapshot := server.typers
+		before := make([]string, len(snapshot))
+		for i, typer := range snapshot {
+			before[i] = typer.mimeType
+		}
+
+		require.True(t, server.RemoveMimeType(snapshot[len(snapshot)/2].mimeType))
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.heic$`), "image/heic", false))
+		for i, typer := range snapshot {
+			assert.Equal(t, before[i], typer.mimeType)
+		}
+	})
+
+	t.Run("MaxTypers caps registrations", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		var logged []error
+		server.ErrorLogFunc = func(r *http.Request, err error) {
+			assert.Nil(t, r)
+			logged = append(logged, err)
+		}
+		server.MaxTypers = len(server.typers) + 1
+
+		assert.True(t, server.RegisterMimeType(regexp.MustCompile(`\.heic$`), "image/heic", false))
+		assert.False(t, server.RegisterMimeType(regexp.MustCompile(`\.jxl$`), "image/jxl", false))
+		assert.False(t, server.IsMimeTypeRegistered("image/jxl"))
+		require.Len(t, logged, 1)
+		assert.ErrorIs(t, logged[0], ErrTooManyTypers)
+
+		require.True(t, server.RemoveMimeType("image/heic"))
+		assert.True(t, server.RegisterMimeType(regexp.MustCompile(`\.jxl$`), "image/jxl", false))
+	})
+}
+
+func TestListMimeTypes(t *testing.T) {
+	t.Run("Lists typers in match order", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.heic$`), "image/heic", true))
+
+		mappings := server.ListMimeTypes()
+		require.Len(t, mappings, len(server.typers))
+		assert.Equal(t, "image/heic", mappings[0].MimeType)
+		assert.Equal(t, `\.heic$`, mappings[0].Expr.String())
+		for i, typer := range server.typers {
+			assert.Equal(t, typer.mimeType, mappings[i].MimeType)
+		}
+	})
+
+	t.Run("Removing a middle typer keeps earlier results intact", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		before := server.ListMimeTypes()
+		expected := make([]string, len(before))
+		for i, mapping := range before {
+			expected[i] = mapping.MimeType
+		}
+
+		middle := middleTyper(server).mimeType
+		require.True(t, server.RemoveMimeType(middle))
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.jxl$`), "image/jxl", false))
+
+		for i, mapping := range before {
+			assert.Equal(t, expected[i], mapping.MimeType)
+		}
+		after := server.ListMimeTypes()
+		assert.Len(t, after, len(before))
+		for _, mapping := range after {
+			assert.NotEqual(t, middle, mapping.MimeType)
+		}
+	})
+
+	t.Run("Modifying the result does not affect the server", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		mappings := server.ListMimeTypes()
+		mappings[0].MimeType = "changed/type"
+		assert.NotEqual(t, "changed/type", server.typers[0].mimeType)
+	})
+}
+
+func TestSetDefaultMimeType(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+
+	t.Run("Octet-stream by default", func(t *testing.T) {
+		assert.Equal(t, mimeTypeUnknown, server.inferMimeType("test.unknown"))
+	})
+
+	t.Run("Used when no typer matches", func(t *testing.T) {
+		server.SetDefaultMimeType(mimeTypeText)
+		defer server.SetDefaultMimeType("")
+		assert.Equal(t, utf8Type(mimeTypeText), server.inferMimeType("test.unknown"))
+		assert.Equal(t, utf8Type(mimeTypeCSS), server.inferMimeType("test.css"))
+
+		req := httptest.NewRequest("GET", "/assets/test.unknown", nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		assert.Equal(t, utf8Type(mimeTypeText), w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Runs after typers registered later", func(t *testing.T) {
+		server.SetDefaultMimeType(mimeTypeText)
+		defer server.SetDefaultMimeType("")
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.unknown$`), "application/x-unknown", false))
+		defer server.RemoveMimeType("application/x-unknown")
+		assert.Equal(t, "application/x-unknown", server.inferMimeType("test.unknown"))
+		assert.Equal(t, utf8Type(mimeTypeText), server.inferMimeType("test.other"))
+	})
+
+	t.Run("Empty restores octet-stream", func(t *testing.T) {
+		server.SetDefaultMimeType(mimeTypeText)
+		server.SetDefaultMimeType("")
+		assert.Equal(t, mimeTypeUnknown, server.inferMimeType("test.unknown"))
+	})
+}
+
+func TestSniffContentType(t *testing.T) {
+	pngHeader := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	files := fstest.MapFS{
+		"image.dat":  &fstest.MapFile{Data: pngHeader},
+		"report.bin": &fstest.MapFile{Data: []byte("%PDF-1.7\n")},
+		"blob.dat":   &fstest.MapFile{Data: []byte{0x00, 0x01, 0x02, 0x03}},
+		"fake.css":   &fstest.MapFile{Data: pngHeader},
+	}
+	get := func(server *AssetServer, p string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", p, nil))
+		return recorder
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedType string
+	}{
+		{"PNG with unknown extension", "/assets/image.dat", "image/png"},
+		{"PDF with unknown extension", "/assets/report.bin", "application/pdf"},
+		{"Undetectable content", "/assets/blob.dat", mimeTypeUnknown},
+		{"Extension takes precedence", "/assets/fake.css", utf8Type(mimeTypeCSS)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", files)
+			require.Nil(t, err)
+			server.SniffContentType = true
+
+			recorder := get(server, tt.path)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tt.expectedType, recorder.Header().Get("Content-Type"))
+		})
+	}
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+
+		assert.Equal(t, mimeTypeUnknown, get(server, "/assets/image.dat").Header().Get("Content-Type"))
+	})
+
+	t.Run("Undetectable content uses the default type", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.SniffContentType = true
+		server.SetDefaultMimeType("text/plain")
+
+		assert.Equal(t, "image/png", get(server, "/assets/image.dat").Header().Get("Content-Type"))
+		assert.Equal(t, utf8Type("text/plain"), get(server, "/assets/blob.dat").Header().Get("Content-Type"))
+	})
+
+	t.Run("Compressed variants are not sniffed", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", fstest.MapFS{"zipped.dat.br": &fstest.MapFile{Data: pngHeader}})
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		server.SniffContentType = true
+		req := httptest.NewRequest("GET", "/assets/zipped.dat", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+
+		assert.Equal(t, "br", recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, mimeTypeUnknown, recorder.Header().Get("Content-Type"))
+	})
+}
+
 func TestPermissionErrors(t *testing.T) {
 	t.Run("Permission error handling", func(t *testing.T) {
 		// Test that DefaultErrFunc properly handles permission errors
@@ -566,6 +1387,1252 @@ func TestPermissionErrors(t *testing.T) {
 
 		assert.Equal(t, http.StatusForbidden, w.Code)
 		assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
-		assert.Equal(t, fs.ErrPermission.Error(), w.Body.String())
+		assert.Equal(t, "Forbidden", w.Body.String())
+	})
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+	server.Maintenance = &MaintenanceConfig{
+		File:       "maintenance.html",
+		RetryAfter: 120,
+		Exempt:     regexp.MustCompile(`^health`),
+	}
+
+	t.Run("Existing asset serves maintenance page", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeHTML), w.Header().Get("Content-Type"))
+		assert.Equal(t, "120", w.Header().Get("Retry-After"))
+		assert.Equal(t, "<h1>Back soon</h1>", w.Body.String())
+	})
+
+	t.Run("Missing asset serves maintenance page", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/nonexistent.txt", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "<h1>Back soon</h1>", w.Body.String())
+	})
+
+	t.Run("Exempt path is served normally", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/health.txt", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Missing maintenance page uses ErrFunc", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.Maintenance = &MaintenanceConfig{File: "missing.html"}
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Server RetryAfterSeconds is the fallback", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.RetryAfterSeconds = 30
+		server.Maintenance = &MaintenanceConfig{File: "maintenance.html"}
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+		server.Maintenance.RetryAfter = 90
+		w = httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("No Retry-After when unset", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.Maintenance = &MaintenanceConfig{File: "maintenance.html"}
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		_, found := w.Header()["Retry-After"]
+		assert.False(t, found)
+	})
+
+	t.Run("Disabling maintenance resumes serving", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.Maintenance = &MaintenanceConfig{File: "maintenance.html"}
+		server.Maintenance = nil
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "body { color: blue; }", w.Body.String())
+	})
+}
+
+func TestServeFile(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+
+	t.Run("Serves fixed file for any URL", func(t *testing.T) {
+		handler := server.ServeFile("test.txt")
+		for _, path := range []string{"/robots.txt", "/", "/assets/test.css"} {
+			req := httptest.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, path)
+			assert.Equal(t, utf8Type(mimeTypeText), w.Header().Get("Content-Type"), path)
+			assert.Equal(t, "plain text", w.Body.String(), path)
+		}
+	})
+
+	t.Run("Applies FSPrefix and brotli", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.BrotliSuffix = ".br"
+		handler := server.ServeFile("test.css")
+		req := httptest.NewRequest("GET", "/style.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+
+		server.BrotliSuffix = ""
+		server.FSPrefix = "prefix/"
+		handler = server.ServeFile("script.js")
+		w = httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "prefixed js", w.Body.String())
+	})
+
+	t.Run("Missing fixed file uses ErrFunc", func(t *testing.T) {
+		handler := server.ServeFile("missing.txt")
+		req := httptest.NewRequest("GET", "/robots.txt", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestPathAnchoredMimeTypes(t *testing.T) {
+	files := fstest.MapFS{
+		"js/app.js.map":     &fstest.MapFile{Data: []byte(`{"version":3}`)},
+		"maps/world.map":    &fstest.MapFile{Data: []byte("map-data")},
+		"js/app.js.map.br":  &fstest.MapFile{Data: []byte("compressed-map")},
+		"public/js/sw.map":  &fstest.MapFile{Data: []byte(`{"version":3}`)},
+		"public/maps/a.map": &fstest.MapFile{Data: []byte("map-data")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.map$`), "application/x-navimap", true))
+	require.True(t, server.RegisterMimeType(regexp.MustCompile(`^js/.*\.map$`), "application/x-sourcemap", true))
+
+	t.Run("Inference uses the full path", func(t *testing.T) {
+		assert.Equal(t, "application/x-sourcemap", server.inferMimeType("js/app.js.map"))
+		assert.Equal(t, "application/x-navimap", server.inferMimeType("maps/world.map"))
+		assert.Equal(t, "application/x-navimap", server.inferMimeType("other/js/app.js.map"))
+	})
+
+	t.Run("Served path matches directory pattern", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/js/app.js.map", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-sourcemap", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Brotli suffix is stripped before matching", func(t *testing.T) {
+		server.BrotliSuffix = ".br"
+		defer func() { server.BrotliSuffix = "" }()
+		req := httptest.NewRequest("GET", "/assets/js/app.js.map", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "application/x-sourcemap", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("FSPrefix is not part of the matched path", func(t *testing.T) {
+		server.FSPrefix = "public/"
+		defer func() { server.FSPrefix = "" }()
+		req := httptest.NewRequest("GET", "/assets/js/sw.map", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-sourcemap", w.Header().Get("Content-Type"))
+
+		req = httptest.NewRequest("GET", "/assets/maps/a.map", nil)
+		w = httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, "application/x-navimap", w.Header().Get("Content-Type"))
+	})
+}
+
+func TestForceContentType(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+	server.ForceContentType = mimeTypePNG
+
+	t.Run("Forced type overrides inference", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/test.unknown", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, mimeTypePNG, w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Brotli encoding still applies", func(t *testing.T) {
+		server.BrotliSuffix = ".br"
+		defer func() { server.BrotliSuffix = "" }()
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, mimeTypePNG, w.Header().Get("Content-Type"))
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "compressed-css-data", w.Body.String())
+	})
+
+	t.Run("Empty value restores inference", func(t *testing.T) {
+		server.ForceContentType = ""
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
+	})
+}
+
+func TestServeBytes(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+	server.HeaderFunc = DefaultHeaderFunc
+
+	t.Run("Applies mime inference and headers", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/generated", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeBytes(w, req, "generated/config.json", []byte(`{"generated":true}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeJSON), w.Header().Get("Content-Type"))
+		assert.Equal(t, "private, max-age=604800", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, `{"generated":true}`, w.Body.String())
+	})
+
+	t.Run("Name need not exist in the filesystem", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/generated", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeBytes(w, req, "nonexistent.css", []byte("a{}"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Identity refusal returns 406", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/generated", nil)
+		req.Header.Set("Accept-Encoding", "identity;q=0")
+		w := httptest.NewRecorder()
+
+		server.ServeBytes(w, req, "generated.txt", []byte("text"))
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("Not dated by a file of the same name", func(t *testing.T) {
+		modTime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		files := fstest.MapFS{"gen.json": &fstest.MapFile{Data: []byte(`{"v":1}`), ModTime: modTime}}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		var dated time.Time
+		server.AssetHeaderFunc = func(w http.ResponseWriter, r *http.Request, asset AssetInfo) {
+			dated = asset.ModTime()
+		}
+		req := httptest.NewRequest("GET", "/generated", nil)
+		req.Header.Set("If-Modified-Since", modTime.Add(time.Hour).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+
+		server.ServeBytes(w, req, "gen.json", []byte(`{"v":2}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+		assert.True(t, dated.IsZero())
+		assert.Equal(t, `{"v":2}`, w.Body.String())
+	})
+}
+
+func TestContentNegotiatedErrFunc(t *testing.T) {
+	tests := []struct {
+		name           string
+		accept         string
+		err            error
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{"JSON not found", "application/json", fs.ErrNotExist, http.StatusNotFound, mimeTypeJSON, `{"error":"not found"}`},
+		{"JSON forbidden", "application/json", fs.ErrPermission, http.StatusForbidden, mimeTypeJSON, `{"error":"forbidden"}`},
+		{"Plain text by default", "", fs.ErrNotExist, http.StatusNotFound, "text/plain; charset=utf-8", "not found"},
+		{"Wildcard prefers plain text", "*/*", errors.New("boom"), http.StatusInternalServerError, "text/plain; charset=utf-8", "internal server error"},
+		{"Browser gets HTML", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", fs.ErrNotExist, http.StatusNotFound, "text/html; charset=utf-8", ""},
+		{"Quality values are respected", "text/html;q=0.5, application/json", ErrNotAcceptable, http.StatusNotAcceptable, mimeTypeJSON, `{"error":"not acceptable"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/assets/missing.css", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			ContentNegotiatedErrFunc(w, r, tt.err)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), "<h1>"+http.StatusText(tt.expectedStatus)+"</h1>")
+			}
+		})
+	}
+
+	t.Run("Used by ServeHTTP", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.ErrFunc = ContentNegotiatedErrFunc
+		req := httptest.NewRequest("GET", "/assets/nonexistent.txt", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, `{"error":"not found"}`, w.Body.String())
+	})
+}
+
+func TestExtensionConflicts(t *testing.T) {
+	t.Run("Conflicting non-priority registration is refused", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.False(t, server.RegisterMimeType(regexp.MustCompile(`\.json$`), "application/ld+json", false))
+		assert.False(t, server.IsMimeTypeRegistered("application/ld+json"))
+	})
+
+	t.Run("Priority registration overrides", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.True(t, server.RegisterMimeType(regexp.MustCompile(`\.json$`), "application/ld+json", true))
+		assert.Equal(t, utf8Type("application/ld+json"), server.inferMimeType("data.json"))
+	})
+
+	t.Run("Complex patterns are not checked", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.True(t, server.RegisterMimeType(regexp.MustCompile(`^api/.*\.json$`), "application/ld+json", false))
+	})
+
+	t.Run("SetMimeTypeForExtension adds new extension", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.Nil(t, server.SetMimeTypeForExtension("yaml", "application/yaml"))
+		assert.Nil(t, server.SetMimeTypeForExtension(".yml", "application/yaml"))
+		assert.Equal(t, "application/yaml", server.inferMimeType("config.yaml"))
+		assert.Equal(t, "application/yaml", server.inferMimeType("config.yml"))
+	})
+
+	t.Run("SetMimeTypeForExtension refuses conflicts", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.Equal(t, ErrExtensionConflict, server.SetMimeTypeForExtension("json", "application/ld+json"))
+		assert.Equal(t, utf8Type(mimeTypeJSON), server.inferMimeType("data.json"))
+	})
+
+	t.Run("SetMimeTypeForExtension detects overlapping patterns", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.gz$`), "application/gzip", false))
+		assert.Equal(t, ErrExtensionConflict, server.SetMimeTypeForExtension("tar.gz", "application/x-gtar"))
+	})
+
+	t.Run("SetMimeTypeForExtension respects MaxTypers", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.MaxTypers = len(server.typers) + 1
+		assert.Nil(t, server.SetMimeTypeForExtension("yaml", "application/yaml"))
+		assert.ErrorIs(t, server.SetMimeTypeForExtension("toml", "application/toml"), ErrTooManyTypers)
+		assert.Equal(t, mimeTypeUnknown, server.inferMimeType("config.toml"))
+	})
+
+	t.Run("SetMimeTypeForExtension is idempotent", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		count := len(server.typers)
+		assert.Nil(t, server.SetMimeTypeForExtension("css", mimeTypeCSS))
+		assert.Equal(t, count, len(server.typers))
+	})
+
+	t.Run("SetMimeTypeForExtension validates input", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.Equal(t, ErrBadMimeMapping, server.SetMimeTypeForExtension("", "text/plain"))
+		assert.Equal(t, ErrBadMimeMapping, server.SetMimeTypeForExtension("txt", ""))
+	})
+}
+
+func TestSimpleExtension(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+		ok       bool
+	}{
+		{`\.css$`, "css", true},
+		{`\.tar\.gz$`, "tar.gz", true},
+		{`\.css`, "", false},
+		{`^js/.*\.map$`, "", false},
+		{`(?i)\.css$`, "", false},
+		{`\.(css|scss)$`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			ext, ok := simpleExtension(regexp.MustCompile(tt.pattern))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, ext)
+		})
+	}
+}
+
+func TestRootRoute(t *testing.T) {
+	site := fstest.MapFS{
+		"index.html":       &fstest.MapFile{Data: []byte("home")},
+		"about/index.html": &fstest.MapFile{Data: []byte("about")},
+		"css/site.css":     &fstest.MapFile{Data: []byte("body{}")},
+		"empty/file.txt":   &fstest.MapFile{Data: []byte("no index here")},
+	}
+	server, err := NewAssetServer("/", site)
+	require.Nil(t, err)
+	server.IndexFile = "index.html"
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{"Site root serves index", "/", http.StatusOK, utf8Type(mimeTypeHTML), "home"},
+		{"Nested directory serves index", "/about/", http.StatusOK, utf8Type(mimeTypeHTML), "about"},
+		{"Asset at full path", "/css/site.css", http.StatusOK, utf8Type(mimeTypeCSS), "body{}"},
+		{"Explicit index file", "/index.html", http.StatusOK, utf8Type(mimeTypeHTML), "home"},
+		{"Missing asset", "/css/missing.css", http.StatusNotFound, "", ""},
+		{"Directory without index", "/empty/", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Directories are not found without IndexFile", func(t *testing.T) {
+		server, err := NewAssetServer("/", site)
+		require.Nil(t, err)
+		for _, path := range []string{"/", "/about/"} {
+			req := httptest.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code, path)
+		}
+	})
+
+	t.Run("IndexFile applies to the route of prefixed servers", func(t *testing.T) {
+		server, err := NewAssetServer("/docs/", site)
+		require.Nil(t, err)
+		server.IndexFile = "index.html"
+		req := httptest.NewRequest("GET", "/docs/", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "home", w.Body.String())
+	})
+}
+
+// failingWriter is a ResponseWriter whose body writes fail part way through
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	err error
+}
+
+func (f *failingWriter) Write(data []byte) (int, error) {
+	n := len(data) / 2
+	f.ResponseRecorder.Write(data[:n])
+	return n, f.err
+}
+
+func TestWriteErrors(t *testing.T) {
+	resetErr := errors.New("stream reset")
+
+	t.Run("Write error is passed to ErrorLogFunc", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		var logged []error
+		server.ErrorLogFunc = func(r *http.Request, err error) {
+			assert.Equal(t, "/assets/test.css", r.URL.Path)
+			logged = append(logged, err)
+		}
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := &failingWriter{ResponseRecorder: httptest.NewRecorder(), err: resetErr}
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, logged, 1)
+		assert.True(t, errors.Is(logged[0], resetErr))
+		assert.Contains(t, logged[0].Error(), "test.css after 10 of 21 bytes")
+	})
+
+	t.Run("Nil ErrorLogFunc ignores write errors", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := &failingWriter{ResponseRecorder: httptest.NewRecorder(), err: resetErr}
+
+		assert.NotPanics(t, func() { server.ServeHTTP(w, req) })
+	})
+
+	t.Run("Successful writes are not logged", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.ErrorLogFunc = func(r *http.Request, err error) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestRegisteredExtensions(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.Equal(t, []string{".css", ".js", ".html", `(^|/)manifest\.json$|\.webmanifest$`, ".json", ".png", ".woff2", ".woff", ".jpeg", ".jpg", ".txt", ".wasm", ".svg", ".webp", ".avif", ".mp4", ".ico", ".map"},
+			server.RegisteredExtensions())
+	})
+
+	t.Run("Complex patterns are reported verbatim", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`^js/.*\.map$`), "application/x-sourcemap", true))
+		require.Nil(t, server.SetMimeTypeForExtension("tar.gz", "application/x-gtar"))
+		extensions := server.RegisteredExtensions()
+		assert.Equal(t, `^js/.*\.map$`, extensions[0])
+		assert.Equal(t, ".tar.gz", extensions[len(extensions)-1])
+	})
+
+	t.Run("Duplicates are omitted", func(t *testing.T) {
+		server, err := NewAssetServerWithOptions("/assets/", testFiles, WithMimeTypes(map[string]string{
+			"css": "text/x-css",
+		}, false))
+		require.Nil(t, err)
+		extensions := server.RegisteredExtensions()
+		assert.Equal(t, len(buildDefaultTypers()), len(extensions))
+		assert.Equal(t, ".css", extensions[0])
+	})
+}
+
+func TestPrerenderForBots(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html":                 &fstest.MapFile{Data: []byte("spa shell")},
+		"app.js":                     &fstest.MapFile{Data: []byte("app()")},
+		"prerendered/index.html":     &fstest.MapFile{Data: []byte("home snapshot")},
+		"prerendered/blog/post.html": &fstest.MapFile{Data: []byte("post snapshot")},
+		"blog/post.html":             &fstest.MapFile{Data: []byte("spa post")},
+	}
+	server, err := NewAssetServer("/", files)
+	require.Nil(t, err)
+	server.IndexFile = "index.html"
+	server.BotUserAgents = []*regexp.Regexp{regexp.MustCompile(`(?i)googlebot|bingbot`)}
+	server.PrerenderDir = "prerendered"
+
+	const browser = "Mozilla/5.0 (X11; Linux x86_64) Firefox/130.0"
+	const bot = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	tests := []struct {
+		name         string
+		path         string
+		userAgent    string
+		expectedBody string
+	}{
+		{"Browser gets SPA shell", "/", browser, "spa shell"},
+		{"Bot gets snapshot", "/", bot, "home snapshot"},
+		{"Bot gets nested snapshot", "/blog/post.html", bot, "post snapshot"},
+		{"Browser gets nested original", "/blog/post.html", browser, "spa post"},
+		{"Bot without snapshot gets original", "/app.js", bot, "app()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, "User-Agent", w.Header().Get("Vary"))
+		})
+	}
+
+	t.Run("Snapshot keeps the requested mime type", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/blog/post.html", nil)
+		req.Header.Set("User-Agent", bot)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, utf8Type(mimeTypeHTML), w.Header().Get("Content-Type"))
+	})
+
+	t.Run("No Vary without bot patterns", func(t *testing.T) {
+		server, err := NewAssetServer("/", files)
+		require.Nil(t, err)
+		req := httptest.NewRequest("GET", "/app.js", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, "", w.Header().Get("Vary"))
+	})
+}
+
+func TestSPAFallback(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<div id=app></div>")},
+		"app.js":     &fstest.MapFile{Data: []byte("route()")},
+	}
+	server, err := NewAssetServer("/", files)
+	require.Nil(t, err)
+	server.SPAFallback = "index.html"
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{"Missing route serves fallback", "/users/42/settings", http.StatusOK, utf8Type(mimeTypeHTML), "<div id=app></div>"},
+		{"Unknown extension serves fallback", "/users/jane.doe", http.StatusOK, utf8Type(mimeTypeHTML), "<div id=app></div>"},
+		{"Existing asset is served", "/app.js", http.StatusOK, utf8Type(mimeTypeJS), "route()"},
+		{"Missing stylesheet is not found", "/css/missing.css", http.StatusNotFound, "", ""},
+		{"Missing script is not found", "/missing.js", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Other errors are not masked", func(t *testing.T) {
+		server, err := NewAssetServer("/", errorFS{})
+		require.Nil(t, err)
+		server.SPAFallback = "permission_error"
+		req := httptest.NewRequest("GET", "/users", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Missing fallback file", func(t *testing.T) {
+		server, err := NewAssetServer("/", files)
+		require.Nil(t, err)
+		server.SPAFallback = "app.html"
+		req := httptest.NewRequest("GET", "/users", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestQueryStringsIgnored(t *testing.T) {
+	counting := &countingFS{files: fstest.MapFS{
+		"app.js": &fstest.MapFile{Data: []byte("app()")},
+	}}
+	cfs, err := NewDefaultCachingFS(counting)
+	require.NoError(t, err)
+	server, err := NewAssetServer("/assets/", cfs)
+	require.Nil(t, err)
+
+	paths := []string{
+		"/assets/app.js",
+		"/assets/app.js?v=123",
+		"/assets/app.js?v=456#section",
+		"/assets/app.js%3Fv=789",
+		"/assets/app.js%23fragment",
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, utf8Type(mimeTypeJS), w.Header().Get("Content-Type"))
+			assert.Equal(t, "app()", w.Body.String())
+		})
+	}
+
+	t.Run("Cache is not fragmented", func(t *testing.T) {
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+}
+
+func TestStripQuery(t *testing.T) {
+	assert.Equal(t, "app.js", stripQuery("app.js"))
+	assert.Equal(t, "app.js", stripQuery("app.js?v=1"))
+	assert.Equal(t, "app.js", stripQuery("app.js#top"))
+	assert.Equal(t, "dir/app.js", stripQuery("dir/app.js#a?b"))
+	assert.Equal(t, "", stripQuery("?v=1"))
+}
+
+func TestWASMContentType(t *testing.T) {
+	files := fstest.MapFS{"module.wasm": &fstest.MapFile{Data: []byte("\x00asm\x01\x00\x00\x00")}}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+
+	req := httptest.NewRequest("GET", "/assets/module.wasm", nil)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// instantiateStreaming rejects anything but the exact type, parameters included
+	assert.Equal(t, []string{"application/wasm"}, recorder.Header().Values("Content-Type"))
+}
+
+func TestWebManifestContentType(t *testing.T) {
+	server, err := NewAssetServer("/assets/", testFiles)
+	require.Nil(t, err)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"manifest.json", utf8Type(mimeTypeWebManifest)},
+		{"app/manifest.json", utf8Type(mimeTypeWebManifest)},
+		{"site.webmanifest", utf8Type(mimeTypeWebManifest)},
+		{"asset-manifest.json", utf8Type(mimeTypeJSON)},
+		{"manifest.json.map", utf8Type(mimeTypeJSON)},
+		{"data/config.json", utf8Type(mimeTypeJSON)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, server.inferMimeType(tt.path))
+		})
+	}
+}
+
+// utf8Type is mimeType with the charset DefaultCharset adds by default
+func utf8Type(mimeType string) string {
+	return mimeType + "; charset=utf-8"
+}
+
+func TestDefaultCharset(t *testing.T) {
+	get := func(server *AssetServer, p string) string {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", p, nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return recorder.Header().Get("Content-Type")
+	}
+
+	t.Run("Text types get utf-8 by default", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+
+		assert.Equal(t, "text/css; charset=utf-8", get(server, "/assets/test.css"))
+		assert.Equal(t, "application/json; charset=utf-8", get(server, "/assets/test.json"))
+	})
+
+	t.Run("Binary types never get a charset", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+
+		for _, p := range []string{"/assets/test.png", "/assets/test.woff2", "/assets/test.jpg", "/assets/test.unknown"} {
+			assert.NotContains(t, get(server, p), "charset", p)
+		}
+	})
+
+	t.Run("Empty disables the charset", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.DefaultCharset = ""
+
+		assert.Equal(t, mimeTypeCSS, get(server, "/assets/test.css"))
+	})
+
+	t.Run("Custom charset", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.DefaultCharset = "iso-8859-1"
+
+		assert.Equal(t, "text/css; charset=iso-8859-1", get(server, "/assets/test.css"))
+	})
+
+	t.Run("Registered charset is kept", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		require.True(t, server.RegisterMimeType(regexp.MustCompile(`\.csv$`), "text/csv; charset=utf-16", false))
+
+		assert.Equal(t, "text/csv; charset=utf-16", server.inferMimeType("report.csv"))
+	})
+
+	t.Run("Structured syntax suffixes are text", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		assert.Equal(t, "image/svg+xml; charset=utf-8", server.inferMimeType("logo.svg"))
+		assert.Equal(t, utf8Type(mimeTypeWebManifest), server.inferMimeType("manifest.json"))
+	})
+}
+
+func TestTimingAllowOrigin(t *testing.T) {
+	t.Run("Not sent by default", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/test.css", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Timing-Allow-Origin"))
+	})
+
+	for _, origin := range []string{"*", "https://app.example.com"} {
+		t.Run(origin, func(t *testing.T) {
+			server, err := NewAssetServer("/assets/", testFiles)
+			require.Nil(t, err)
+			server.TimingAllowOrigin = origin
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/test.css", nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, origin, w.Header().Get("Timing-Allow-Origin"))
+		})
+	}
+
+	t.Run("Sent with 304 responses", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.TimingAllowOrigin = "*"
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.Header.Set("If-None-Match", DefaultETagFunc(testFiles["test.css"].Data))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Timing-Allow-Origin"))
+	})
+
+	t.Run("Not sent with errors", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.TimingAllowOrigin = "*"
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/missing.css", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Timing-Allow-Origin"))
+	})
+}
+
+func TestPathTraversal(t *testing.T) {
+	for _, urlPath := range []string{
+		"/assets/../../etc/passwd",
+		"/assets/..%2f..%2fetc%2fpasswd",
+		"/assets/%2e%2e/%2e%2e/etc/passwd",
+		"/assets/css/../../../etc/passwd",
+		"/assets/..",
+		"/assets//etc/passwd",
+	} {
+		t.Run(urlPath, func(t *testing.T) {
+			files := &countingFS{files: fstest.MapFS{
+				"test.css": &fstest.MapFile{Data: testFiles["test.css"].Data},
+			}}
+			server, err := NewAssetServer("/assets/", files)
+			require.Nil(t, err)
+			server.IndexFile = "index.html"
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest("GET", urlPath, nil))
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Zero(t, files.reads.Load())
+			assert.Zero(t, files.opens.Load())
+		})
+	}
+
+	t.Run("Dotted filenames are not traversal", func(t *testing.T) {
+		files := &countingFS{files: fstest.MapFS{
+			"..data.txt": &fstest.MapFile{Data: []byte("data")},
+		}}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/..data.txt", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "data", w.Body.String())
+	})
+}
+
+func TestBadRequestPath(t *testing.T) {
+	newServer := func(t *testing.T) (*AssetServer, *countingFS) {
+		files := &countingFS{files: fstest.MapFS{
+			"test.css":     &fstest.MapFile{Data: testFiles["test.css"].Data},
+			"not-found.md": &fstest.MapFile{Data: []byte("# missing")},
+		}}
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		return server, files
+	}
+	badEscape := func() *http.Request {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.URL.Path = "/assets/%zz"
+		req.URL.RawPath = "/assets/%zz"
+		return req
+	}
+	nullByte := func() *http.Request {
+		req := httptest.NewRequest("GET", "/assets/test.css", nil)
+		req.URL.Path = "/assets/test.css\x00.txt"
+		return req
+	}
+
+	for name, newRequest := range map[string]func() *http.Request{"Bad escape": badEscape, "Null byte": nullByte} {
+		t.Run(name, func(t *testing.T) {
+			server, files := newServer(t)
+			var got error
+			server.ErrFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+				got = err
+				DefaultErrFunc(w, r, err)
+			}
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, newRequest())
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "Bad Request", w.Body.String())
+			assert.ErrorIs(t, got, ErrBadRequestPath)
+			assert.Zero(t, files.reads.Load())
+			assert.Zero(t, files.opens.Load())
+		})
+	}
+
+	t.Run("Not served as the not found page", func(t *testing.T) {
+		server, _ := newServer(t)
+		server.NotFoundFile = "not-found.md"
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, nullByte())
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Encoded percent is a missing file", func(t *testing.T) {
+		server, _ := newServer(t)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/%25zz", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRequestCancellation(t *testing.T) {
+	blocking := newBlockingFS(fstest.MapFS{"slow.css": &fstest.MapFile{Data: []byte("body{}")}})
+	cfs, err := NewDefaultCachingFS(blocking)
+	require.NoError(t, err)
+	server, err := NewAssetServer("/assets/", cfs)
+	require.Nil(t, err)
+	var got error
+	server.ErrorLogFunc = func(r *http.Request, err error) {
+		got = err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/assets/slow.css", nil).WithContext(ctx))
+	}()
+	<-blocking.started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ServeHTTP did not return after the request was canceled")
+	}
+	assert.ErrorIs(t, got, context.Canceled)
+}
+
+func TestRedirectFunc(t *testing.T) {
+	moved := map[string]string{
+		"/assets/old.css":  "/assets/test.css",
+		"/assets/old/":     "/assets/new/",
+		"/assets/temp.css": "/assets/test.css",
+	}
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.RedirectFunc = func(r *http.Request) (string, int, bool) {
+			location, ok := moved[r.URL.Path]
+			if r.URL.Path == "/assets/temp.css" {
+				return location, http.StatusTemporaryRedirect, ok
+			}
+			return location, 0, ok
+		}
+		return server
+	}
+	get := func(server *AssetServer, p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+		return w
+	}
+
+	t.Run("Matched redirect", func(t *testing.T) {
+		w := get(newServer(t), "/assets/old.css")
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/assets/test.css", w.Header().Get("Location"))
+		assert.NotContains(t, w.Body.String(), "blue")
+	})
+
+	t.Run("Status from RedirectFunc", func(t *testing.T) {
+		w := get(newServer(t), "/assets/temp.css")
+		assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+		assert.Equal(t, "/assets/test.css", w.Header().Get("Location"))
+	})
+
+	t.Run("Directories redirect before index resolution", func(t *testing.T) {
+		w := get(newServer(t), "/assets/old/")
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/assets/new/", w.Header().Get("Location"))
+	})
+
+	t.Run("Unmatched path serves normally", func(t *testing.T) {
+		w := get(newServer(t), "/assets/test.css")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, testFiles["test.css"].Data, w.Body.Bytes())
+		assert.Empty(t, w.Header().Get("Location"))
+
+		assert.Equal(t, http.StatusNotFound, get(newServer(t), "/assets/missing.css").Code)
+	})
+
+	t.Run("Traversal is rejected first", func(t *testing.T) {
+		server := newServer(t)
+		server.RedirectFunc = func(r *http.Request) (string, int, bool) {
+			t.Errorf("RedirectFunc called for %s", r.URL.Path)
+			return "", 0, false
+		}
+		assert.Equal(t, http.StatusNotFound, get(server, "/assets/../../etc/passwd").Code)
+	})
+}
+
+func TestNotFoundFile(t *testing.T) {
+	files := fstest.MapFS{
+		"app.js":   &fstest.MapFile{Data: []byte("console.log('app')")},
+		"404.html": &fstest.MapFile{Data: []byte("<h1>Not here</h1>")},
+	}
+
+	t.Run("Serves the page with 404", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.NotFoundFile = "404.html"
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/missing.js", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "<h1>Not here</h1>", w.Body.String())
+		assert.Equal(t, utf8Type(mimeTypeHTML), w.Header().Get("Content-Type"))
+		assert.Equal(t, "17", w.Header().Get("Content-Length"))
+	})
+
+	t.Run("Existing assets unaffected", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.NotFoundFile = "404.html"
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/app.js", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "console.log('app')", w.Body.String())
+	})
+
+	t.Run("HEAD omits the body", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.NotFoundFile = "404.html"
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("HEAD", "/assets/missing.js", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Other errors still use ErrFunc", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.NotFoundFile = "404.html"
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("POST", "/assets/app.js", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.NotContains(t, w.Body.String(), "Not here")
+	})
+
+	t.Run("Unreadable page falls back to ErrFunc", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.NotFoundFile = "missing-404.html"
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/missing.js", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "Not Found", w.Body.String())
+	})
+
+	t.Run("Unset keeps DefaultErrFunc", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/missing.js", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "Not Found", w.Body.String())
+	})
+}
+
+func TestPrefixStripped(t *testing.T) {
+	newServer := func(t *testing.T) *AssetServer {
+		server, err := NewAssetServerWithOptions("/", testFiles, WithPrefixStripped())
+		require.Nil(t, err)
+		return server
+	}
+
+	for _, prefix := range []string{"/static", "/static/"} {
+		t.Run("http.StripPrefix "+prefix, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle("/static/", http.StripPrefix(prefix, newServer(t)))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/static/test.css", nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "body { color: blue; }", w.Body.String())
+			assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
+		})
+	}
+
+	t.Run("Missing files", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		http.StripPrefix("/static", newServer(t)).ServeHTTP(w, httptest.NewRequest("GET", "/static/missing.css", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Traversal still rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		http.StripPrefix("/static", newServer(t)).ServeHTTP(w, httptest.NewRequest("GET", "/static//etc/passwd", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Route is not trimmed", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", testFiles)
+		require.Nil(t, err)
+		server.PrefixStripped = true
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/test.css", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "assets/test.css", server.routePath("/assets/test.css"))
 	})
 }
@@ -0,0 +1,102 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// streamAsset serves files larger than StreamThreshold, and with UseServeContent seekable
+// files of any size, straight from an fs.File instead of reading them into memory. It
+// returns false, having written nothing, when the file should be read normally,
+// including when it can't be opened so the usual error handling applies, and when the
+// client refuses identity, so the buffered path can serve a compressed variant or 406.
+func (server *AssetServer) streamAsset(w http.ResponseWriter, r *http.Request, requestedPath string) bool {
+	if identityRefused(r.Header.Get("Accept-Encoding")) {
+		return false
+	}
+	filePath := server.fsPath(requestedPath)
+	if !server.FollowSymlinks && symlinked(server.files, server.FSPrefix, filePath) {
+		return false
+	}
+	info, err := fs.Stat(server.files, filePath)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	large := server.StreamThreshold > 0 && info.Size() > int64(server.StreamThreshold)
+	if !large && !server.UseServeContent {
+		return false
+	}
+	file, err := server.files.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	seeker, seekable := file.(io.ReadSeeker)
+	if !large && !seekable {
+		return false
+	}
+	if server.HeaderFunc != nil {
+		server.HeaderFunc(w, nil)
+	}
+	server.setCacheControl(w, requestedPath)
+	if server.AssetHeaderFunc != nil {
+		server.AssetHeaderFunc(w, r, AssetInfo{Path: requestedPath, modTime: info.ModTime()})
+	}
+	mimeType := server.ForceContentType
+	if mimeType == "" {
+		mimeType = server.inferMimeType(requestedPath)
+	}
+	w.Header().Set("Content-Type", mimeType)
+	server.setDisposition(w, requestedPath)
+	if language := server.pathLanguage(requestedPath); language != "" {
+		w.Header().Set("Content-Language", language)
+	}
+	if server.TimingAllowOrigin != "" {
+		w.Header().Set("Timing-Allow-Origin", server.TimingAllowOrigin)
+	}
+	var modTime time.Time
+	if server.LastModified {
+		modTime = info.ModTime()
+	}
+	if seekable {
+		// ServeContent handles Range, If-Range, If-Modified-Since, and HEAD from the modtime,
+		// and sends no Last-Modified for a zero one
+		http.ServeContent(w, r, requestedPath, modTime, seeker)
+		return true
+	}
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if r.Header.Get("If-None-Match") == "" && notModifiedSince(r.Header.Get("If-Modified-Since"), modTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return true
+	}
+	n, err := io.Copy(w, file)
+	if err != nil && server.ErrorLogFunc != nil {
+		server.ErrorLogFunc(r, fmt.Errorf("streaming %s after %d of %d bytes: %w", requestedPath, n, info.Size(), err))
+	}
+	return true
+}
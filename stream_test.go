@@ -0,0 +1,276 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unseekableFS hides io.Seeker on the files it opens, like archive or network filesystems
+type unseekableFS struct {
+	fs.ReadFileFS
+}
+
+func (u unseekableFS) Open(name string) (fs.File, error) {
+	file, err := u.ReadFileFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{file}, nil
+}
+
+// statFS answers Stat without opening files, like os.DirFS
+type statFS struct {
+	*countingFS
+}
+
+func (s statFS) Stat(name string) (fs.FileInfo, error) {
+	return s.files.Stat(name)
+}
+
+func TestStreamThreshold(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), 1000)
+	modTime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	newCounting := func() *countingFS {
+		return &countingFS{files: fstest.MapFS{
+			"video.mp4": &fstest.MapFile{Data: large, ModTime: modTime},
+			"small.css": &fstest.MapFile{Data: []byte("body{}")},
+		}}
+	}
+	newServer := func(t *testing.T, files fs.ReadFileFS) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.StreamThreshold = 1024
+		return server
+	}
+
+	t.Run("Large file streams", func(t *testing.T) {
+		counting := newCounting()
+		server := newServer(t, counting)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/video.mp4", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, large, w.Body.Bytes())
+		assert.Equal(t, mimeTypeMP4, w.Header().Get("Content-Type"))
+		assert.Equal(t, strconv.Itoa(len(large)), w.Header().Get("Content-Length"))
+		assert.Equal(t, modTime.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+		assert.Zero(t, counting.reads.Load())
+	})
+
+	t.Run("Small file still buffers", func(t *testing.T) {
+		counting := newCounting()
+		server := newServer(t, counting)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/small.css", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "body{}", w.Body.String())
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		counting := newCounting()
+		server := newServer(t, counting)
+		server.StreamThreshold = 0
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/video.mp4", nil))
+
+		assert.Equal(t, large, w.Body.Bytes())
+		assert.Equal(t, int64(1), counting.reads.Load())
+	})
+
+	t.Run("Range request", func(t *testing.T) {
+		server := newServer(t, newCounting())
+		req := httptest.NewRequest("GET", "/assets/video.mp4", nil)
+		req.Header.Set("Range", "bytes=10-19")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "0123456789", w.Body.String())
+	})
+
+	t.Run("If-Modified-Since", func(t *testing.T) {
+		server := newServer(t, newCounting())
+		req := httptest.NewRequest("GET", "/assets/video.mp4", nil)
+		req.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("Unseekable file is copied", func(t *testing.T) {
+		server := newServer(t, unseekableFS{newCounting()})
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/video.mp4", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, large, w.Body.Bytes())
+		assert.Equal(t, strconv.Itoa(len(large)), w.Header().Get("Content-Length"))
+	})
+
+	t.Run("HEAD on an unseekable file", func(t *testing.T) {
+		server := newServer(t, unseekableFS{newCounting()})
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("HEAD", "/assets/video.mp4", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+		assert.Equal(t, strconv.Itoa(len(large)), w.Header().Get("Content-Length"))
+	})
+
+	t.Run("Small file is not opened to check its size", func(t *testing.T) {
+		counting := newCounting()
+		server := newServer(t, statFS{counting})
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/small.css", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Zero(t, counting.opens.Load())
+	})
+
+	t.Run("Refused identity falls back to the buffered path", func(t *testing.T) {
+		server := newServer(t, newCounting())
+		req := httptest.NewRequest("GET", "/assets/video.mp4", nil)
+		req.Header.Set("Accept-Encoding", "br, identity;q=0")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.NotEqual(t, large, w.Body.Bytes())
+	})
+
+	t.Run("LastModified disabled", func(t *testing.T) {
+		for _, files := range []fs.ReadFileFS{newCounting(), unseekableFS{newCounting()}} {
+			server := newServer(t, files)
+			server.LastModified = false
+			req := httptest.NewRequest("GET", "/assets/video.mp4", nil)
+			req.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("Last-Modified"))
+			assert.Equal(t, large, w.Body.Bytes())
+		}
+	})
+
+	t.Run("If-Modified-Since on an unseekable file", func(t *testing.T) {
+		server := newServer(t, unseekableFS{newCounting()})
+		req := httptest.NewRequest("GET", "/assets/video.mp4", nil)
+		req.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, modTime.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("Missing file uses the normal error handling", func(t *testing.T) {
+		server := newServer(t, newCounting())
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/assets/missing.mp4", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestUseServeContent(t *testing.T) {
+	modTime := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	files := fstest.MapFS{
+		"site.css":    &fstest.MapFile{Data: []byte("body{color:red}"), ModTime: modTime},
+		"site.css.br": &fstest.MapFile{Data: []byte("compressed")},
+	}
+	newServer := func(t *testing.T, files fs.ReadFileFS) *AssetServer {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.UseServeContent = true
+		return server
+	}
+
+	t.Run("Sends Last-Modified", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newServer(t, files).ServeHTTP(w, httptest.NewRequest("GET", "/assets/site.css", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "body{color:red}", w.Body.String())
+		assert.Equal(t, utf8Type(mimeTypeCSS), w.Header().Get("Content-Type"))
+		assert.Equal(t, modTime.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+	})
+
+	t.Run("Conditional GET returns 304", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/site.css", nil)
+		req.Header.Set("If-Modified-Since", modTime.Add(time.Hour).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		newServer(t, files).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+	})
+
+	t.Run("Modified since returns 200", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/site.css", nil)
+		req.Header.Set("If-Modified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		newServer(t, files).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("If-Range with a stale date sends the whole file", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/site.css", nil)
+		req.Header.Set("Range", "bytes=0-3")
+		req.Header.Set("If-Range", modTime.Add(-time.Hour).Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		newServer(t, files).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "body{color:red}", w.Body.String())
+	})
+
+	t.Run("Brotli variants are not used", func(t *testing.T) {
+		server := newServer(t, files)
+		server.BrotliSuffix = ".br"
+		req := httptest.NewRequest("GET", "/assets/site.css", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "body{color:red}", w.Body.String())
+	})
+
+	t.Run("Unseekable files are served normally", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newServer(t, unseekableFS{files}).ServeHTTP(w, httptest.NewRequest("GET", "/assets/site.css", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+	})
+}
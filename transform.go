@@ -0,0 +1,63 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"bytes"
+	"strings"
+)
+
+// StaticaTransformFunc rewrites an asset's contents before it is sent. Transforms must
+// return a new slice rather than modifying data in place, since data may be shared
+// with a cache.
+type StaticaTransformFunc func(filePath string, mimeType string, data []byte) []byte
+
+// Line ending styles accepted by NormalizeLineEndings
+const (
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+// NormalizeLineEndings returns a transform which rewrites the line endings of text/* assets
+// to style, either LineEndingsLF or LineEndingsCRLF. Other styles leave data unchanged.
+func NormalizeLineEndings(style string) StaticaTransformFunc {
+	return func(filePath string, mimeType string, data []byte) []byte {
+		if !strings.HasPrefix(mimeType, "text/") {
+			return data
+		}
+		switch style {
+		case LineEndingsLF:
+			if !bytes.Contains(data, []byte("\r\n")) {
+				return data
+			}
+			return bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+		case LineEndingsCRLF:
+			if !bytes.Contains(data, []byte("\n")) {
+				return data
+			}
+			lf := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+			return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+		}
+		return data
+	}
+}
+
+// applyTransforms runs the server's transforms over data in order
+func (server *AssetServer) applyTransforms(filePath string, mimeType string, data []byte) []byte {
+	for _, transform := range server.Transforms {
+		data = transform(filePath, mimeType, data)
+	}
+	return data
+}
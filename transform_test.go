@@ -0,0 +1,123 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLineEndings(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    string
+		mimeType string
+		input    string
+		expected string
+	}{
+		{"CRLF to LF", LineEndingsLF, mimeTypeText, "a\r\nb\r\n", "a\nb\n"},
+		{"LF stays LF", LineEndingsLF, mimeTypeText, "a\nb\n", "a\nb\n"},
+		{"LF to CRLF", LineEndingsCRLF, mimeTypeCSS, "a\nb\n", "a\r\nb\r\n"},
+		{"Mixed to CRLF", LineEndingsCRLF, mimeTypeHTML, "a\r\nb\nc", "a\r\nb\r\nc"},
+		{"Non-text untouched", LineEndingsLF, mimeTypePNG, "a\r\nb", "a\r\nb"},
+		{"JSON untouched", LineEndingsLF, mimeTypeJSON, "{\r\n}", "{\r\n}"},
+		{"Unknown style untouched", "cr", mimeTypeText, "a\r\nb", "a\r\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NormalizeLineEndings(tt.style)("file", tt.mimeType, []byte(tt.input))
+			assert.Equal(t, tt.expected, string(result))
+		})
+	}
+
+	t.Run("Input is not modified", func(t *testing.T) {
+		input := []byte("a\r\nb")
+		NormalizeLineEndings(LineEndingsLF)("file.txt", mimeTypeText, input)
+		assert.Equal(t, "a\r\nb", string(input))
+	})
+}
+
+func TestTransforms(t *testing.T) {
+	files := fstest.MapFS{
+		"windows.txt":    &fstest.MapFile{Data: []byte("line one\r\nline two\r\n")},
+		"windows.txt.br": &fstest.MapFile{Data: []byte("compressed\r\n")},
+		"image.png":      &fstest.MapFile{Data: []byte("png\r\ndata")},
+	}
+	server, err := NewAssetServer("/assets/", files)
+	require.Nil(t, err)
+	server.Transforms = []StaticaTransformFunc{NormalizeLineEndings(LineEndingsLF)}
+
+	t.Run("Text asset is normalized", func(t *testing.T) {
+		var headerData []byte
+		server.HeaderFunc = func(w http.ResponseWriter, data []byte) {
+			headerData = data
+		}
+		defer func() { server.HeaderFunc = nil }()
+		req := httptest.NewRequest("GET", "/assets/windows.txt", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "line one\nline two\n", w.Body.String())
+		assert.Equal(t, "line one\nline two\n", string(headerData))
+		assert.Equal(t, "line one\r\nline two\r\n", string(files["windows.txt"].Data))
+	})
+
+	t.Run("Binary asset is untouched", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/assets/image.png", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, "png\r\ndata", w.Body.String())
+	})
+
+	t.Run("Compressed variant is untouched", func(t *testing.T) {
+		server.BrotliSuffix = ".br"
+		defer func() { server.BrotliSuffix = "" }()
+		req := httptest.NewRequest("GET", "/assets/windows.txt", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, brotliEncoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "compressed\r\n", w.Body.String())
+	})
+
+	t.Run("Transforms run in order", func(t *testing.T) {
+		server, err := NewAssetServer("/assets/", files)
+		require.Nil(t, err)
+		server.Transforms = []StaticaTransformFunc{
+			NormalizeLineEndings(LineEndingsLF),
+			func(filePath string, mimeType string, data []byte) []byte {
+				return append([]byte(filePath+":"), data...)
+			},
+		}
+		req := httptest.NewRequest("GET", "/assets/windows.txt", nil)
+		w := httptest.NewRecorder()
+
+		server.ServeHTTP(w, req)
+
+		assert.Equal(t, "windows.txt:line one\nline two\n", w.Body.String())
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var ErrDanglingReference = errors.New("referenced asset does not exist")
+
+// referenceRegex matches quoted src and href attribute values
+var referenceRegex = regexp.MustCompile(`(?i)\b(?:src|href)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+// VerifyReferences checks that every src and href attribute in the HTML files at htmlPaths
+// refers to a file or directory in fsys, typically as a build step before deploying the
+// filesystem an AssetServer will serve. References starting with "/" are resolved from
+// the root of fsys, others relative to the HTML file. External URLs, fragments, and
+// data: and mailto: links are skipped. Each dangling reference is reported as an error
+// wrapping ErrDanglingReference, combined with errors.Join.
+func VerifyReferences(fsys fs.FS, htmlPaths []string) error {
+	var errs []error
+	for _, htmlPath := range htmlPaths {
+		data, err := fs.ReadFile(fsys, htmlPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, match := range referenceRegex.FindAllStringSubmatch(string(data), -1) {
+			reference := match[1] + match[2]
+			target, local := resolveReference(htmlPath, reference)
+			if !local {
+				continue
+			}
+			if _, err := fs.Stat(fsys, target); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s references %s", ErrDanglingReference, htmlPath, reference))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveReference maps a reference found in htmlPath to a path in the filesystem.
+// Returns false for references which don't name a local file.
+func resolveReference(htmlPath, reference string) (string, bool) {
+	reference = stripQuery(strings.TrimSpace(reference))
+	if reference == "" || strings.HasPrefix(reference, "//") {
+		return "", false
+	}
+	if scheme, _, found := strings.Cut(reference, ":"); found && !strings.Contains(scheme, "/") {
+		return "", false
+	}
+	if strings.HasPrefix(reference, "/") {
+		reference = path.Clean(reference)[1:]
+	} else {
+		reference = path.Join(path.Dir(htmlPath), reference)
+	}
+	if reference == "" {
+		reference = "."
+	}
+	return reference, true
+}
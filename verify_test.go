@@ -0,0 +1,88 @@
+// Copyright 2025 Poiesic Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statica
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveReference(t *testing.T) {
+	tests := []struct {
+		name      string
+		htmlPath  string
+		reference string
+		expected  string
+		local     bool
+	}{
+		{"Relative", "docs/index.html", "style.css", "docs/style.css", true},
+		{"Parent directory", "docs/index.html", "../app.js", "app.js", true},
+		{"Root relative", "docs/index.html", "/img/logo.png", "img/logo.png", true},
+		{"Query and fragment", "index.html", "app.js?v=3#main", "app.js", true},
+		{"Root", "docs/index.html", "/", ".", true},
+		{"Absolute URL", "index.html", "https://cdn.example.com/lib.js", "", false},
+		{"Protocol relative", "index.html", "//cdn.example.com/lib.js", "", false},
+		{"Fragment", "index.html", "#top", "", false},
+		{"Mailto", "index.html", "mailto:team@example.com", "", false},
+		{"Data URI", "index.html", "data:image/png;base64,AAAA", "", false},
+		{"Empty", "index.html", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, local := resolveReference(tt.htmlPath, tt.reference)
+			assert.Equal(t, tt.local, local)
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+}
+
+func TestVerifyReferences(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte(`<!DOCTYPE html>
+<link rel="stylesheet" href="/css/site.css">
+<script src='app.js?v=2'></script>
+<a href="https://example.com">external</a>
+<a href="#top">top</a>
+<a HREF="docs/">docs</a>`)},
+		"docs/index.html": &fstest.MapFile{Data: []byte(`<img src="../img/missing.png"><a href="/">home</a><script src="guide.js"></script>`)},
+		"css/site.css":    &fstest.MapFile{Data: []byte("body{}")},
+		"app.js":          &fstest.MapFile{Data: []byte("run()")},
+	}
+
+	t.Run("All references exist", func(t *testing.T) {
+		assert.NoError(t, VerifyReferences(files, []string{"index.html"}))
+	})
+
+	t.Run("Dangling references are listed", func(t *testing.T) {
+		err := VerifyReferences(files, []string{"index.html", "docs/index.html"})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrDanglingReference))
+		errs := err.(interface{ Unwrap() []error }).Unwrap()
+		require.Len(t, errs, 2)
+		assert.Contains(t, errs[0].Error(), "docs/index.html references ../img/missing.png")
+		assert.Contains(t, errs[1].Error(), "docs/index.html references guide.js")
+	})
+
+	t.Run("Missing HTML file", func(t *testing.T) {
+		err := VerifyReferences(files, []string{"missing.html"})
+		assert.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+}